@@ -1,18 +1,24 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"text/tabwriter"
 	"time"
 
 	"github.com/itprodirect/go-hello-world/internal/checker"
+	"github.com/itprodirect/go-hello-world/internal/metrics"
 	"github.com/itprodirect/go-hello-world/internal/workerpool"
 )
 
@@ -27,6 +33,10 @@ func run(args []string, stdout, stderr io.Writer) int {
 }
 
 func runWithChecker(args []string, stdout, stderr io.Writer, check checkFunc) int {
+	if len(args) > 0 && args[0] == "slo" {
+		return runSLO(args[1:], stdout, stderr, time.Now())
+	}
+
 	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
 	fs.SetOutput(stderr)
 
@@ -34,6 +44,14 @@ func runWithChecker(args []string, stdout, stderr io.Writer, check checkFunc) in
 	workers := fs.Int("workers", 8, "number of concurrent workers")
 	timeout := fs.Int("timeout", 5000, "default timeout per check in ms")
 	jsonOutput := fs.Bool("json", false, "output results as JSON lines")
+	historyFile := fs.String("history", "", "append results to this JSON-lines history file and report SLOs")
+	rate := fs.Float64("rate", 0, "maximum checks started per second (0 = unlimited)")
+	perHost := fs.Int("per-host", 0, "maximum concurrent checks per hostname (0 = unlimited)")
+	traceFile := fs.String("trace", "", "write a Chrome trace-event JSON file of check queueing and run times")
+	deadline := fs.Duration("deadline", 0, "skip checks not started within this long of the run start (0 = no deadline)")
+	statsdAddr := fs.String("statsd", "", "push metrics to this StatsD agent (host:port) over UDP")
+	dogstatsdAddr := fs.String("dogstatsd", "", "push metrics to this DogStatsD agent (host:port), with labels as tags")
+	otlpEndpoint := fs.String("otlp", "", "push metrics to this OTLP/HTTP endpoint (e.g. http://localhost:4318)")
 
 	if err := fs.Parse(args); err != nil {
 		return 2
@@ -47,6 +65,18 @@ func runWithChecker(args []string, stdout, stderr io.Writer, check checkFunc) in
 		fmt.Fprintf(stderr, "invalid timeout: %d (must be >= 1ms)\n", *timeout)
 		return 1
 	}
+	if *rate < 0 {
+		fmt.Fprintf(stderr, "invalid rate: %g (must be >= 0)\n", *rate)
+		return 1
+	}
+	if *perHost < 0 {
+		fmt.Fprintf(stderr, "invalid per-host: %d (must be >= 0)\n", *perHost)
+		return 1
+	}
+	if *deadline < 0 {
+		fmt.Fprintf(stderr, "invalid deadline: %s (must be >= 0)\n", *deadline)
+		return 1
+	}
 
 	var targets []checker.Target
 	if *targetsFile == "" {
@@ -67,58 +97,323 @@ func runWithChecker(args []string, stdout, stderr io.Writer, check checkFunc) in
 		}
 	}
 
+	counters := metrics.NewCounters()
+	checks := counters.MustCounterVec("healthcheck_checks_total", "status")
+	counters.SetHelp("healthcheck_checks_total", "Checks run, by result status.")
+	latency := counters.MustSummary("healthcheck_check_latency_seconds", nil)
+	counters.SetHelp("healthcheck_check_latency_seconds", "Check latency in seconds, skipped checks excluded.")
+
+	exporter, closeExporter, err := newExporter(*statsdAddr, *dogstatsdAddr, *otlpEndpoint)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer closeExporter()
+
+	// From here on the pusher and the pool's panic handler also write to
+	// stderr, from their own goroutines.
+	stderr = &syncWriter{w: stderr}
+	var pusher *metrics.Pusher
+	if exporter != nil {
+		pusher = metrics.NewPusher(counters, exporter, exportInterval).
+			WithErrorHandler(func(err error) { fmt.Fprintf(stderr, "push metrics: %v\n", err) })
+		pusher.Start()
+		// Early error returns must not leave the push loop running. Stop
+		// is idempotent, so this is a no-op after the final push below.
+		defer func() {
+			flushCtx, cancelFlush := context.WithTimeout(context.Background(), exportTimeout)
+			defer cancelFlush()
+			_ = pusher.Stop(flushCtx)
+		}()
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	start := time.Now()
-	pool := workerpool.New[checker.Target, checker.Result](*workers)
-	results := pool.Run(ctx, targets, workerpool.TaskFunc[checker.Target, checker.Result](check))
-	elapsed := time.Since(start)
+	pool := workerpool.New[checker.Target, checker.Result](*workers).
+		WithPanicHandler(func(target checker.Target, err *workerpool.PanicError) checker.Result {
+			fmt.Fprintf(stderr, "check %q %v\n%s\n", target.Name, err, err.Stack)
+			return checker.Result{
+				Name:   target.Name,
+				Type:   target.Type,
+				Target: cmp.Or(target.URL, target.Host),
+				Status: "error",
+				Detail: err.Error(),
+			}
+		}).
+		WithRateLimit(*rate, 1).
+		WithKeyLimit(targetHostname, *perHost)
+
+	var recorder *workerpool.TraceRecorder[checker.Target]
+	if *traceFile != "" {
+		recorder = workerpool.NewTraceRecorder(func(target checker.Target) string { return target.Name })
+		pool.WithHooks(recorder.Hooks())
+	}
 
+	jobs := make([]workerpool.Scheduled[checker.Target], len(targets))
+	for i, target := range targets {
+		jobs[i] = workerpool.Scheduled[checker.Target]{Value: target, Priority: target.Priority}
+		if *deadline > 0 {
+			jobs[i].Deadline = start.Add(*deadline)
+		}
+	}
+	task := workerpool.TaskFunc[checker.Target, checker.Result](check)
+	progress := func(checker.Result) {}
+	if !*jsonOutput {
+		// The table waits for every result, so report each check on
+		// stderr as soon as it finishes. Checks that panic are reported
+		// from the deferred call and skipped ones from the outcome loop,
+		// so the count always reaches the total.
+		var finished atomic.Int64
+		progress = func(result checker.Result) {
+			fmt.Fprintf(stderr, "[%d/%d] %s %s %s\n",
+				finished.Add(1), len(targets),
+				checker.StatusEmoji(result.Status), result.Name, result.Latency.Round(time.Millisecond))
+		}
+		task = func(ctx context.Context, target checker.Target) (result checker.Result) {
+			returned := false
+			defer func() {
+				if !returned {
+					progress(checker.Result{Name: target.Name, Status: "error"})
+					return
+				}
+				progress(result)
+			}()
+			result = check(ctx, target)
+			returned = true
+			return result
+		}
+	}
+	outcomes := pool.StreamScheduled(ctx, jobs, task)
+
+	// Checks start by priority but results arrive in target order. JSON
+	// lines are written as soon as they can be; the table needs every
+	// result for column widths, so it is rendered at the end after the
+	// progress lines above.
+	var encoder *json.Encoder
 	if *jsonOutput {
-		encoder := json.NewEncoder(stdout)
-		for _, result := range results {
+		encoder = json.NewEncoder(stdout)
+	}
+	results := make([]checker.Result, 0, len(targets))
+	for outcome := range outcomes {
+		result := outcome.Value
+		if outcome.Skipped {
+			result = skippedResult(targets[outcome.Index])
+			progress(result)
+		} else {
+			latency.Observe(result.Latency.Seconds())
+		}
+		checks.WithLabels("status", result.Status).Inc()
+		results = append(results, result)
+		if encoder != nil {
 			if err := encoder.Encode(result); err != nil {
 				fmt.Fprintf(stderr, "encode result: %v\n", err)
 				return 1
 			}
 		}
-	} else {
+	}
+	elapsed := time.Since(start)
+
+	if recorder != nil {
+		if err := writeTrace(*traceFile, recorder); err != nil {
+			fmt.Fprintf(stderr, "write trace: %v\n", err)
+			return 1
+		}
+	}
+
+	if !*jsonOutput {
 		if err := printTable(stdout, results); err != nil {
 			fmt.Fprintf(stderr, "render table: %v\n", err)
 			return 1
 		}
 	}
 
-	up, down, errCount := 0, 0, 0
+	up, down, errCount, skipped := 0, 0, 0, 0
 	for _, result := range results {
 		switch result.Status {
 		case "up":
 			up++
 		case "down":
 			down++
+		case statusSkipped:
+			skipped++
 		default:
 			errCount++
 		}
 	}
 
-	fmt.Fprintf(
-		stderr,
-		"\n--- %d checks in %s | %d up | %d down | %d errors ---\n",
+	summary := fmt.Sprintf(
+		"--- %d checks in %s | %d up | %d down | %d errors",
 		len(results),
 		elapsed.Round(time.Millisecond),
 		up,
 		down,
 		errCount,
 	)
+	if skipped > 0 {
+		summary += fmt.Sprintf(" | %d skipped", skipped)
+	}
+	fmt.Fprintf(stderr, "\n%s ---\n", summary)
+	if line := latencyLine(latency.Snapshot()); line != "" {
+		fmt.Fprintln(stderr, line)
+	}
 
-	if down > 0 || errCount > 0 {
+	// Nothing scrapes a one-shot run, so the final push is what delivers
+	// its metrics. A failed push still exits 1, but only after the run is
+	// recorded in the history.
+	pushFailed := false
+	if pusher != nil {
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), exportTimeout)
+		err := pusher.Stop(flushCtx)
+		cancelFlush()
+		if err != nil {
+			fmt.Fprintf(stderr, "push metrics: %v\n", err)
+			pushFailed = true
+		}
+	}
+
+	if *historyFile != "" {
+		now := time.Now()
+		// Skipped checks say nothing about the target, so they stay out of
+		// the SLO history.
+		var ran []checker.Result
+		for _, result := range results {
+			if result.Status != statusSkipped {
+				ran = append(ran, result)
+			}
+		}
+		if err := checker.AppendHistory(*historyFile, ran, now); err != nil {
+			fmt.Fprintf(stderr, "record history: %v\n", err)
+			return 1
+		}
+
+		reports, err := evaluateSLOs(targets, *historyFile, now)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		printSLOSummary(stderr, reports)
+	}
+
+	if down > 0 || errCount > 0 || skipped > 0 || pushFailed {
 		return 1
 	}
 
 	return 0
 }
 
+// syncWriter serialises writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+func writeTrace(path string, recorder *workerpool.TraceRecorder[checker.Target]) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := recorder.WriteChromeTrace(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+const (
+	// exportInterval is how often metrics are pushed during long runs.
+	exportInterval = 10 * time.Second
+	// exportTimeout bounds the final push.
+	exportTimeout = 5 * time.Second
+)
+
+// newExporter builds the exporters selected by flags; it returns a nil
+// Exporter when none is. close releases their sockets.
+func newExporter(statsdAddr, dogstatsdAddr, otlpEndpoint string) (metrics.Exporter, func(), error) {
+	var exporters []metrics.Exporter
+	var closers []func() error
+	closeAll := func() {
+		for _, c := range closers {
+			_ = c()
+		}
+	}
+
+	for _, agent := range []struct {
+		addr   string
+		flavor metrics.StatsDFlavor
+	}{{statsdAddr, metrics.StatsDPlain}, {dogstatsdAddr, metrics.DogStatsD}} {
+		if agent.addr == "" {
+			continue
+		}
+		statsd, err := metrics.DialStatsD(agent.addr, agent.flavor)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		exporters = append(exporters, statsd)
+		closers = append(closers, statsd.Close)
+	}
+
+	if otlpEndpoint != "" {
+		otlp, err := metrics.NewOTLP(otlpEndpoint, "healthcheck")
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		exporters = append(exporters, otlp)
+	}
+
+	if len(exporters) == 0 {
+		return nil, closeAll, nil
+	}
+	return metrics.Exporters(exporters...), closeAll, nil
+}
+
+// latencyLine renders the check latency quantiles, or "" when no check ran.
+func latencyLine(snap metrics.SummarySnapshot) string {
+	if snap.Count == 0 {
+		return ""
+	}
+	parts := make([]string, len(snap.Quantiles))
+	for i, q := range snap.Quantiles {
+		d := time.Duration(q.Value * float64(time.Second))
+		parts[i] = fmt.Sprintf("p%g %s", q.Q*100, d.Round(time.Millisecond))
+	}
+	return "--- latency " + strings.Join(parts, " | ") + " ---"
+}
+
+// statusSkipped marks a check that did not start before --deadline.
+const statusSkipped = "skipped"
+
+func skippedResult(target checker.Target) checker.Result {
+	return checker.Result{
+		Name:   target.Name,
+		Type:   target.Type,
+		Target: cmp.Or(target.URL, target.Host),
+		Status: statusSkipped,
+		Detail: "deadline passed before the check started",
+	}
+}
+
+// targetHostname returns the lowercased hostname a target connects to.
+func targetHostname(target checker.Target) string {
+	if target.URL != "" {
+		if u, err := url.Parse(target.URL); err == nil && u.Hostname() != "" {
+			return strings.ToLower(u.Hostname())
+		}
+	}
+	return strings.ToLower(target.Host)
+}
+
 func printTable(w io.Writer, results []checker.Result) error {
 	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(writer, "STATUS\tNAME\tTYPE\tTARGET\tLATENCY\tDETAIL")
@@ -133,7 +428,8 @@ func printTable(w io.Writer, results []checker.Result) error {
 			result.Type,
 			result.Target,
 			result.Latency.Round(time.Millisecond),
-			result.Detail,
+			// Keep multi-line details (such as content diffs) under DETAIL.
+			strings.ReplaceAll(result.Detail, "\n", "\n\t\t\t\t\t"),
 		)
 		if result.TLS != nil {
 			fmt.Fprintf(writer, " (TLS: %d days left)", result.TLS.DaysLeft)
@@ -4,9 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -79,6 +86,9 @@ func TestRunWithCheckerSuccessReturnsZeroAndJSONLatencyMs(t *testing.T) {
 	if !strings.Contains(stderr.String(), "2 up | 0 down | 0 errors") {
 		t.Fatalf("unexpected summary: %q", stderr.String())
 	}
+	if !strings.Contains(stderr.String(), "--- latency p50 2.5s | p90 2.5s | p99 2.5s ---") {
+		t.Fatalf("missing latency quantiles: %q", stderr.String())
+	}
 }
 
 func TestRunWithCheckerFailureReturnsOne(t *testing.T) {
@@ -111,6 +121,331 @@ func TestRunWithCheckerFailureReturnsOne(t *testing.T) {
 	if !strings.Contains(stderr.String(), "1 up | 1 down | 0 errors") {
 		t.Fatalf("unexpected summary: %q", stderr.String())
 	}
+	// Table mode reports each check on stderr as it finishes.
+	for _, want := range []string{"/2] " + checker.StatusEmoji("up") + " up-target 20ms\n", "/2] " + checker.StatusEmoji("down") + " down-target 20ms\n"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("stderr missing progress %q: %q", want, stderr.String())
+		}
+	}
+}
+
+func TestRunWithCheckerJSONFollowsTargetOrder(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "slow", URL: "https://example.com", Type: "http"},
+		{Name: "medium", URL: "https://example.org", Type: "http"},
+		{Name: "fast", URL: "https://example.net", Type: "http"},
+	})
+	delays := map[string]time.Duration{"slow": 60 * time.Millisecond, "medium": 30 * time.Millisecond}
+
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"--targets", targetsPath, "--json", "--workers", "3"}, &stdout, &stderr, func(ctx context.Context, target checker.Target) checker.Result {
+		time.Sleep(delays[target.Name])
+		return checker.Result{Name: target.Name, Type: target.Type, Status: "up"}
+	})
+	if code != 0 {
+		t.Fatalf("code = %d, want 0; stderr=%q", code, stderr.String())
+	}
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var got checker.Result
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("unmarshal %q: %v", line, err)
+		}
+		names = append(names, got.Name)
+	}
+	if strings.Join(names, ",") != "slow,medium,fast" {
+		t.Fatalf("result order = %v, want target order", names)
+	}
+}
+
+func TestRunWithCheckerRecoversCheckPanic(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "broken", URL: "https://example.com", Type: "http"},
+		{Name: "fine", URL: "https://example.org", Type: "http"},
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"--targets", targetsPath, "--json", "--workers", "1"}, &stdout, &stderr, func(ctx context.Context, target checker.Target) checker.Result {
+		if target.Name == "broken" {
+			panic("nil map write")
+		}
+		return checker.Result{Name: target.Name, Type: target.Type, Status: "up"}
+	})
+	if code != 1 {
+		t.Fatalf("code = %d, want 1; stderr=%q", code, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("json lines = %d, want 2; output=%q", len(lines), stdout.String())
+	}
+	var broken checker.Result
+	if err := json.Unmarshal([]byte(lines[0]), &broken); err != nil {
+		t.Fatalf("unmarshal %q: %v", lines[0], err)
+	}
+	if broken.Status != "error" || broken.Detail != "panic: nil map write" || broken.Target != "https://example.com" {
+		t.Fatalf("panicked result = %+v", broken)
+	}
+	if !strings.Contains(stderr.String(), "goroutine") || !strings.Contains(stderr.String(), "1 up | 0 down | 1 errors") {
+		t.Fatalf("stderr missing stack or summary: %q", stderr.String())
+	}
+}
+
+func TestRunWithCheckerProgressCountsPanicsAndSkips(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "broken", URL: "https://example.com", Type: "http", Priority: 10},
+		{Name: "slow", URL: "https://example.org", Type: "http", Priority: 5},
+		{Name: "late", URL: "https://example.net", Type: "http"},
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"--targets", targetsPath, "--workers", "1", "--deadline", "20ms"}, &stdout, &stderr, func(ctx context.Context, target checker.Target) checker.Result {
+		if target.Name == "broken" {
+			panic("nil map write")
+		}
+		time.Sleep(40 * time.Millisecond)
+		return checker.Result{Name: target.Name, Type: target.Type, Status: "up"}
+	})
+	if code != 1 {
+		t.Fatalf("code = %d, want 1; stderr=%q", code, stderr.String())
+	}
+
+	for _, want := range []string{"] " + checker.StatusEmoji("error") + " broken ", "] " + checker.StatusEmoji("up") + " slow ", "] " + checker.StatusEmoji("skipped") + " late ", "[3/3] "} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("stderr missing progress %q: %q", want, stderr.String())
+		}
+	}
+}
+
+func TestRunWithCheckerPerHostLimit(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "a1", URL: "https://a.example.com/one", Type: "http"},
+		{Name: "a2", URL: "https://A.example.com:8443/two", Type: "http"},
+		{Name: "a3", Host: "a.example.com", Port: 443, Type: "tcp"},
+		{Name: "b1", URL: "https://b.example.com", Type: "http"},
+	})
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	peak := map[string]int{}
+	check := func(ctx context.Context, target checker.Target) checker.Result {
+		host := targetHostname(target)
+		mu.Lock()
+		running[host]++
+		peak[host] = max(peak[host], running[host])
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running[host]--
+		mu.Unlock()
+		return checker.Result{Name: target.Name, Type: target.Type, Status: "up"}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"--targets", targetsPath, "--workers", "4", "--per-host", "1", "--rate", "1000"}, &stdout, &stderr, check)
+	if code != 0 {
+		t.Fatalf("code = %d, want 0; stderr=%q", code, stderr.String())
+	}
+	if peak["a.example.com"] != 1 || peak["b.example.com"] != 1 {
+		t.Fatalf("peak per host = %v, want 1 each", peak)
+	}
+}
+
+func TestRunWithCheckerRejectsNegativeLimits(t *testing.T) {
+	for _, args := range [][]string{{"--rate", "-1"}, {"--per-host", "-2"}} {
+		var stdout, stderr bytes.Buffer
+		code := runWithChecker(args, &stdout, &stderr, nil)
+		if code != 1 || !strings.Contains(stderr.String(), "must be >= 0") {
+			t.Fatalf("%v: code=%d stderr=%q, want validation error", args, code, stderr.String())
+		}
+	}
+}
+
+func TestRunWithCheckerPushesMetrics(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "a", URL: "https://example.com", Type: "http"},
+		{Name: "b", Host: "localhost", Type: "dns"},
+	})
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	var otlpBody []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otlpBody, _ = io.ReadAll(r.Body)
+	}))
+	defer collector.Close()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--targets", targetsPath, "--dogstatsd", udp.LocalAddr().String(), "--otlp", collector.URL}
+	code := runWithChecker(args, &stdout, &stderr, func(ctx context.Context, target checker.Target) checker.Result {
+		status := "up"
+		if target.Name == "b" {
+			status = "down"
+		}
+		return checker.Result{Name: target.Name, Status: status, Latency: 100 * time.Millisecond}
+	})
+	if code != 1 {
+		t.Fatalf("code = %d, want 1 for the down check; stderr=%q", code, stderr.String())
+	}
+
+	_ = udp.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 65536)
+	n, _, err := udp.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no StatsD datagram: %v", err)
+	}
+	for _, want := range []string{"healthcheck_checks_total:1|c|#status:down", "healthcheck_checks_total:1|c|#status:up", "healthcheck_check_latency_seconds_count:2|c"} {
+		if !strings.Contains(string(buf[:n]), want) {
+			t.Errorf("StatsD datagram missing %q:\n%s", want, buf[:n])
+		}
+	}
+
+	if !strings.Contains(string(otlpBody), `"name":"healthcheck_check_latency_seconds"`) {
+		t.Errorf("OTLP request missing latency summary: %s", otlpBody)
+	}
+}
+
+func TestRunWithCheckerRecordsHistoryWhenPushFails(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{{Name: "a", URL: "https://example.com", Type: "http"}})
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--targets", targetsPath, "--otlp", collector.URL, "--history", historyPath}
+	code := runWithChecker(args, &stdout, &stderr, func(ctx context.Context, target checker.Target) checker.Result {
+		return checker.Result{Name: target.Name, Status: "up", Latency: time.Millisecond}
+	})
+	if code != 1 || !strings.Contains(stderr.String(), "push metrics:") {
+		t.Fatalf("code=%d stderr=%q, want the push failure reported and exit 1", code, stderr.String())
+	}
+
+	history, err := os.ReadFile(historyPath)
+	if err != nil || !strings.Contains(string(history), `"name":"a"`) {
+		t.Fatalf("history = %q, %v; want the run recorded despite the failed push", history, err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestRunWithCheckerStopsPusherOnEncodeError(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{{Name: "a", URL: "https://example.com", Type: "http"}})
+	var pushes atomic.Int64
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushes.Add(1)
+	}))
+	defer collector.Close()
+
+	var stderr bytes.Buffer
+	args := []string{"--targets", targetsPath, "--json", "--otlp", collector.URL}
+	code := runWithChecker(args, failingWriter{}, &stderr, func(ctx context.Context, target checker.Target) checker.Result {
+		return checker.Result{Name: target.Name, Status: "up"}
+	})
+	if code != 1 || !strings.Contains(stderr.String(), "encode result: disk full") {
+		t.Fatalf("code=%d stderr=%q, want the encode error reported and exit 1", code, stderr.String())
+	}
+	// Stopping the pusher flushes once; a leaked pusher would not.
+	if got := pushes.Load(); got != 1 {
+		t.Fatalf("pushes = %d, want the final push from stopping the pusher", got)
+	}
+}
+
+func TestRunWithCheckerRejectsBadOTLPEndpoint(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"--otlp", "localhost:4318"}, &stdout, &stderr, nil)
+	if code != 1 || !strings.Contains(stderr.String(), "invalid OTLP endpoint") {
+		t.Fatalf("code=%d stderr=%q, want endpoint error", code, stderr.String())
+	}
+}
+
+func TestRunWithCheckerPriorityAndDeadline(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "batch-job", URL: "https://example.com/slow", Type: "http", Priority: -1},
+		{Name: "api", URL: "https://example.com", Type: "http"},
+		{Name: "core-dns", Host: "example.com", Type: "dns", Priority: 10},
+	})
+
+	var mu sync.Mutex
+	var ran []string
+	check := func(ctx context.Context, target checker.Target) checker.Result {
+		mu.Lock()
+		ran = append(ran, target.Name)
+		mu.Unlock()
+		time.Sleep(30 * time.Millisecond)
+		return checker.Result{Name: target.Name, Type: target.Type, Status: "up"}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"--targets", targetsPath, "--json", "--workers", "1", "--deadline", "45ms"}, &stdout, &stderr, check)
+	if code != 1 {
+		t.Fatalf("code = %d, want 1 for skipped checks; stderr=%q", code, stderr.String())
+	}
+	if strings.Join(ran, ",") != "core-dns,api" {
+		t.Fatalf("ran = %v, want core-dns then api", ran)
+	}
+
+	var statuses []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var got checker.Result
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("unmarshal %q: %v", line, err)
+		}
+		statuses = append(statuses, got.Name+"="+got.Status)
+	}
+	if strings.Join(statuses, ",") != "batch-job=skipped,api=up,core-dns=up" {
+		t.Fatalf("results = %v, want target order with batch-job skipped", statuses)
+	}
+	if !strings.Contains(stderr.String(), "2 up | 0 down | 0 errors | 1 skipped") {
+		t.Fatalf("unexpected summary: %q", stderr.String())
+	}
+}
+
+func TestRunWithCheckerWritesTrace(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "a", URL: "https://example.com", Type: "http"},
+		{Name: "b", URL: "https://example.org", Type: "http"},
+	})
+	tracePath := filepath.Join(t.TempDir(), "trace.json")
+
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"--targets", targetsPath, "--trace", tracePath}, &stdout, &stderr, func(ctx context.Context, target checker.Target) checker.Result {
+		return checker.Result{Name: target.Name, Type: target.Type, Status: "up"}
+	})
+	if code != 0 {
+		t.Fatalf("code = %d, want 0; stderr=%q", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(tracePath)
+	if err != nil {
+		t.Fatalf("read trace: %v", err)
+	}
+	var trace struct {
+		TraceEvents []struct {
+			Name string `json:"name"`
+			Ph   string `json:"ph"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(data, &trace); err != nil {
+		t.Fatalf("decode trace: %v", err)
+	}
+	runs := map[string]bool{}
+	for _, event := range trace.TraceEvents {
+		if event.Ph == "X" {
+			runs[event.Name] = true
+		}
+	}
+	if !runs["a"] || !runs["b"] {
+		t.Fatalf("trace runs = %v, want a and b", runs)
+	}
 }
 
 func TestRunWithCheckerNoTargetsFileUsesDemoMessage(t *testing.T) {
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/itprodirect/go-hello-world/internal/checker"
+)
+
+// runSLO implements the "slo" subcommand: it evaluates every target that
+// declares an SLO against the recorded history and exits 1 if any alert
+// condition holds.
+func runSLO(args []string, stdout, stderr io.Writer, now time.Time) int {
+	fs := flag.NewFlagSet("healthcheck slo", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	targetsFile := fs.String("targets", "", "path to targets JSON file")
+	historyFile := fs.String("history", "", "path to JSON-lines check history")
+	jsonOutput := fs.Bool("json", false, "output reports as JSON lines")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *targetsFile == "" || *historyFile == "" {
+		fmt.Fprintln(stderr, "slo requires --targets and --history")
+		return 1
+	}
+
+	targets, err := checker.LoadTargets(*targetsFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "load targets: %v\n", err)
+		return 1
+	}
+
+	reports, err := evaluateSLOs(targets, *historyFile, now)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if len(reports) == 0 {
+		fmt.Fprintln(stderr, "No targets declare an slo.")
+		return 0
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		for _, report := range reports {
+			if err := encoder.Encode(report); err != nil {
+				fmt.Fprintf(stderr, "encode report: %v\n", err)
+				return 1
+			}
+		}
+	} else if err := printSLOTable(stdout, reports); err != nil {
+		fmt.Fprintf(stderr, "render table: %v\n", err)
+		return 1
+	}
+
+	alerting := 0
+	for _, report := range reports {
+		if len(report.Alerts) > 0 {
+			alerting++
+		}
+	}
+	fmt.Fprintf(stderr, "\n--- %d slos | %d alerting ---\n", len(reports), alerting)
+
+	if alerting > 0 {
+		return 1
+	}
+	return 0
+}
+
+// evaluateSLOs loads history and reports on each target with an SLO.
+func evaluateSLOs(targets []checker.Target, historyFile string, now time.Time) ([]checker.SLOReport, error) {
+	var reports []checker.SLOReport
+	var records []checker.HistoryRecord
+	loaded := false
+
+	for _, target := range targets {
+		if target.SLO == nil {
+			continue
+		}
+		if err := target.SLO.Validate(); err != nil {
+			return nil, fmt.Errorf("target %q slo: %w", target.Name, err)
+		}
+
+		if !loaded {
+			var err error
+			records, err = checker.LoadHistory(historyFile)
+			if err != nil {
+				return nil, fmt.Errorf("load history: %w", err)
+			}
+			loaded = true
+		}
+
+		reports = append(reports, checker.EvaluateSLO(target.Name, *target.SLO, records, now))
+	}
+
+	return reports, nil
+}
+
+func printSLOTable(w io.Writer, reports []checker.SLOReport) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tWINDOW\tSAMPLES\tAVAILABILITY\tBUDGET LEFT\tBURN (window/6h/1h)\tLATENCY\tALERTS")
+	fmt.Fprintln(writer, "----\t------\t-------\t------------\t-----------\t-------------------\t-------\t------")
+
+	for _, report := range reports {
+		fmt.Fprintf(
+			writer,
+			"%s\t%dd\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			report.Name,
+			report.WindowDays,
+			report.Samples,
+			formatAvailability(report),
+			formatBudget(report),
+			formatBurn(report),
+			formatLatency(report),
+			formatAlerts(report),
+		)
+	}
+
+	return writer.Flush()
+}
+
+// printSLOSummary writes one line per report, used after a normal run.
+func printSLOSummary(w io.Writer, reports []checker.SLOReport) {
+	for _, report := range reports {
+		fmt.Fprintf(
+			w,
+			"SLO %s: availability %s | budget %s | burn %s | latency %s | alerts %s\n",
+			report.Name,
+			formatAvailability(report),
+			formatBudget(report),
+			formatBurn(report),
+			formatLatency(report),
+			formatAlerts(report),
+		)
+	}
+}
+
+func formatAvailability(report checker.SLOReport) string {
+	if report.Samples == 0 {
+		return "-"
+	}
+	if report.AvailabilityObjective == 0 {
+		return fmt.Sprintf("%.3f%%", report.Availability)
+	}
+	return fmt.Sprintf("%.3f%% (obj %.3g%%)", report.Availability, report.AvailabilityObjective)
+}
+
+func formatBudget(report checker.SLOReport) string {
+	if report.Samples == 0 || report.AvailabilityObjective == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", report.ErrorBudgetRemaining)
+}
+
+func formatBurn(report checker.SLOReport) string {
+	if report.Samples == 0 || report.AvailabilityObjective == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fx/%.2fx/%.2fx", report.BurnRate, report.SlowBurnRate, report.FastBurnRate)
+}
+
+func formatLatency(report checker.SLOReport) string {
+	if report.Samples == 0 || report.LatencyObjectiveMS == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("p%g %dms (obj %dms)", report.LatencyPercentile, report.LatencyMS, report.LatencyObjectiveMS)
+}
+
+func formatAlerts(report checker.SLOReport) string {
+	if len(report.Alerts) == 0 {
+		return "none"
+	}
+	return strings.Join(report.Alerts, ",")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itprodirect/go-hello-world/internal/checker"
+)
+
+func TestRunWithCheckerHistoryReportsSLO(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "api", URL: "https://example.com", Type: "http", SLO: &checker.SLO{Availability: 99.9, LatencyMS: 300}},
+		{Name: "plain", URL: "https://example.org", Type: "http"},
+	})
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+
+	check := func(ctx context.Context, target checker.Target) checker.Result {
+		return checker.Result{Name: target.Name, Type: target.Type, Status: "up", Latency: 100 * time.Millisecond}
+	}
+
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		code := runWithChecker([]string{"--targets", targetsPath, "--history", historyPath}, &stdout, &stderr, check)
+		if code != 0 {
+			t.Fatalf("run %d: code=%d, stderr=%q", i, code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "SLO api: availability 100.000% (obj 99.9%)") {
+			t.Fatalf("run %d: missing SLO line: %q", i, stderr.String())
+		}
+		if strings.Contains(stderr.String(), "SLO plain") {
+			t.Fatalf("run %d: unexpected SLO line for target without slo: %q", i, stderr.String())
+		}
+	}
+
+	records, err := checker.LoadHistory(historyPath)
+	if err != nil {
+		t.Fatalf("LoadHistory: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("len(records)=%d, want 4", len(records))
+	}
+}
+
+func TestRunSLOCommandAlerts(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "api", URL: "https://example.com", Type: "http", SLO: &checker.SLO{Availability: 99}},
+	})
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+
+	now := time.Now()
+	results := []checker.Result{{Name: "api", Status: "down"}, {Name: "api", Status: "up"}}
+	if err := checker.AppendHistory(historyPath, results, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("AppendHistory: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runWithChecker([]string{"slo", "--targets", targetsPath, "--history", historyPath, "--json"}, &stdout, &stderr, nil)
+	if code != 1 {
+		t.Fatalf("code=%d, want 1; stderr=%q", code, stderr.String())
+	}
+
+	var report checker.SLOReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("decode report %q: %v", stdout.String(), err)
+	}
+	if report.Name != "api" || report.Samples != 2 || report.Availability != 50 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if !strings.Contains(strings.Join(report.Alerts, ","), "fast_burn") {
+		t.Fatalf("alerts=%v, want fast_burn", report.Alerts)
+	}
+	if !strings.Contains(stderr.String(), "1 slos | 1 alerting") {
+		t.Fatalf("unexpected summary: %q", stderr.String())
+	}
+}
+
+func TestRunSLOCommandTable(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "api", URL: "https://example.com", Type: "http", SLO: &checker.SLO{Availability: 99.9, LatencyMS: 300}},
+	})
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := checker.AppendHistory(historyPath, []checker.Result{{Name: "api", Status: "up", Latency: 50 * time.Millisecond}}, time.Now()); err != nil {
+		t.Fatalf("AppendHistory: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runSLO([]string{"--targets", targetsPath, "--history", historyPath}, &stdout, &stderr, time.Now().Add(time.Second))
+	if code != 0 {
+		t.Fatalf("code=%d, want 0; stderr=%q", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "BUDGET LEFT") || !strings.Contains(out, "p95 50ms (obj 300ms)") || !strings.Contains(out, "none") {
+		t.Fatalf("unexpected table: %q", out)
+	}
+}
+
+func TestRunSLOCommandRequiresFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runSLO(nil, &stdout, &stderr, time.Now()); code != 1 {
+		t.Fatalf("code=%d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "requires --targets and --history") {
+		t.Fatalf("unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestRunSLOCommandInvalidObjective(t *testing.T) {
+	targetsPath := writeTargetsFile(t, []checker.Target{
+		{Name: "api", Type: "http", SLO: &checker.SLO{Availability: 100}},
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := runSLO([]string{"--targets", targetsPath, "--history", filepath.Join(t.TempDir(), "h.jsonl")}, &stdout, &stderr, time.Now())
+	if code != 1 || !strings.Contains(stderr.String(), `target "api" slo`) {
+		t.Fatalf("code=%d stderr=%q, want validation error", code, stderr.String())
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -10,13 +11,9 @@ import (
 	"github.com/itprodirect/go-hello-world/internal/greeter"
 	"github.com/itprodirect/go-hello-world/internal/metrics"
 	"github.com/itprodirect/go-hello-world/internal/validator"
+	"github.com/itprodirect/go-hello-world/internal/workerpool"
 )
 
-type workerResult struct {
-	index   int
-	message string
-}
-
 type jsonGreeting struct {
 	Index   int    `json:"index"`
 	Message string `json:"message"`
@@ -51,35 +48,17 @@ func run(args []string, stdout, stderr io.Writer) int {
 	counters := metrics.NewCounters()
 	g := greeter.New(*style)
 
-	jobs := make(chan int)
-	results := make(chan workerResult, *repeat)
-
-	workerCount := *repeat
-	if workerCount > 4 {
-		workerCount = 4
+	sequences := make([]int, *repeat)
+	for i := range sequences {
+		sequences[i] = i + 1
 	}
 
-	for i := 0; i < workerCount; i++ {
-		go func() {
-			for idx := range jobs {
-				sequence := idx + 1
-				message := g.Greet(*name, sequence)
-				counters.Inc("cli_greetings_generated")
-				results <- workerResult{index: idx, message: message}
-			}
-		}()
-	}
-
-	for i := 0; i < *repeat; i++ {
-		jobs <- i
-	}
-	close(jobs)
-
-	orderedMessages := make([]string, *repeat)
-	for i := 0; i < *repeat; i++ {
-		result := <-results
-		orderedMessages[result.index] = result.message
-	}
+	pool := workerpool.New[int, string](min(*repeat, 4))
+	orderedMessages := pool.RunOrdered(context.Background(), sequences, func(ctx context.Context, sequence int) string {
+		message := g.Greet(*name, sequence)
+		counters.Inc("cli_greetings_generated")
+		return message
+	})
 
 	for i, message := range orderedMessages {
 		if *jsonOutput {
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/itprodirect/go-hello-world/internal/config"
 	"github.com/itprodirect/go-hello-world/internal/greeter"
+	"github.com/itprodirect/go-hello-world/internal/logging"
 	"github.com/itprodirect/go-hello-world/internal/metrics"
 	"github.com/itprodirect/go-hello-world/internal/middleware"
 	"github.com/itprodirect/go-hello-world/internal/validator"
@@ -29,10 +32,16 @@ func main() {
 	flag.Parse()
 
 	cfg := config.MustLoad(*cfgPath)
-	logger := log.New(os.Stdout, "", log.LstdFlags)
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.JSONOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		os.Exit(1)
+	}
 	counters := metrics.NewCounters()
 	startedAt := time.Now()
-	logger.Printf("loaded config: %s (port %d)", cfg.Name, cfg.Port)
+	logger.Info("loaded config", "name", cfg.Name, "port", cfg.Port, "log_level", cfg.LogLevel)
+
+	stopSnapshots := startSnapshots(cfg, logger, counters)
 
 	handler := newHandler(cfg, logger, counters)
 
@@ -50,27 +59,85 @@ func main() {
 
 	go runUptimeTicker(ctx, logger, counters, startedAt)
 
+	// ListenAndServe returns as soon as Shutdown begins; shutdownDone is
+	// closed once in-flight requests have drained.
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
-		logger.Println("shutdown signal received")
+		logger.Info("shutdown signal received")
 
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		if err := server.Shutdown(shutdownCtx); err != nil {
-			logger.Printf("server shutdown error: %v", err)
+			logger.Error("server shutdown", "error", err)
 		}
 	}()
 
-	logger.Printf("hello-server listening on http://%s", server.Addr)
+	logger.Info("hello-server listening", "url", "http://"+server.Addr)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Fatalf("server error: %v", err)
+		logger.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+
+	// The final snapshot must include increments from drained requests.
+	<-shutdownDone
+	stopSnapshots()
+	logger.Info("server stopped")
+}
+
+// startSnapshots restores persisted counters and saves them every
+// cfg.SnapshotInterval until the returned function, which takes a final
+// snapshot, is called. It does nothing without cfg.SnapshotPath.
+func startSnapshots(cfg config.AppConfig, logger *slog.Logger, counters *metrics.Counters) (stop func()) {
+	if cfg.SnapshotPath == "" {
+		return func() {}
+	}
+
+	// A corrupt snapshot should not keep the server down; it is logged and
+	// replaced by the next one.
+	if err := counters.Restore(cfg.SnapshotPath, cfg.PersistCounters...); err != nil {
+		logger.Error("restore counters", "path", cfg.SnapshotPath, "error", err)
+	} else {
+		logger.Info("restored counters", "path", cfg.SnapshotPath)
 	}
 
-	logger.Println("server stopped")
+	snapshots := metrics.NewPusher(counters, metrics.NewSnapshotFile(cfg.SnapshotPath, cfg.PersistCounters...), cfg.SnapshotInterval()).
+		WithErrorHandler(func(err error) { logger.Error("snapshot counters", "error", err) })
+	snapshots.Start()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := snapshots.Stop(ctx); err != nil {
+			logger.Error("snapshot counters", "error", err)
+		}
+	}
 }
 
-func newHandler(cfg config.AppConfig, logger *log.Logger, counters *metrics.Counters) http.Handler {
+// responseSeriesLimit caps http_responses_total series. Paths are taken
+// from the raw request, so scans for unknown URLs would otherwise create a
+// series each.
+const responseSeriesLimit = 100
+
+func newHandler(cfg config.AppConfig, logger *slog.Logger, counters *metrics.Counters) http.Handler {
+	// Tag request-scoped log lines with their request and trace ids.
+	logger = slog.New(middleware.ContextHandler(logger.Handler()))
+
+	counters.MustCounterVec("http_responses_total", "method", "path", "status").SetSeriesLimit(responseSeriesLimit)
+	counters.SetHelp("hello_requests", "Greetings served by /hello.")
+	counters.SetHelp("health_requests", "Requests to /health.")
+	counters.SetHelp("metrics_requests", "Scrapes of /metrics.")
+	counters.SetHelp("uptime_ticks", "Uptime ticks logged, one every 5s.")
+	counters.Meter("http_requests_total")
+	counters.Meter("hello_requests")
+
+	registry := metrics.NewRegistry()
+	registry.MustRegister("app", counters)
+	registry.MustRegister("go", metrics.NewGoCollector())
+	registry.MustRegister("process", metrics.NewProcessCollector())
+
 	mux := http.NewServeMux()
 
 	mux.Handle("/hello", middleware.AllowMethods([]string{http.MethodGet},
@@ -80,7 +147,7 @@ func newHandler(cfg config.AppConfig, logger *log.Logger, counters *metrics.Coun
 				name = cfg.DefaultGreet
 			}
 			if err := validator.ValidateName(name); err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				middleware.Error(w, r, err.Error(), http.StatusBadRequest)
 				return
 			}
 
@@ -94,7 +161,7 @@ func newHandler(cfg config.AppConfig, logger *log.Logger, counters *metrics.Coun
 				Message: message,
 				Count:   count,
 			}); err != nil {
-				logger.Printf("encode /hello response: %v", err)
+				logger.ErrorContext(r.Context(), "encode /hello response", "error", err)
 			}
 		}),
 	))
@@ -111,20 +178,58 @@ func newHandler(cfg config.AppConfig, logger *log.Logger, counters *metrics.Coun
 	mux.Handle("/metrics", middleware.AllowMethods([]string{http.MethodGet},
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			counters.Inc("metrics_requests")
-			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-			_, _ = w.Write([]byte(counters.PlainText()))
+			switch r.URL.Query().Get("format") {
+			case "":
+			case "json":
+				writeMetricsJSON(w, r, logger, registry, counters)
+				return
+			default:
+				middleware.Error(w, r, "unknown format (want json, or none for Accept negotiation)", http.StatusBadRequest)
+				return
+			}
+
+			format := metrics.NegotiateFormat(r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", format.ContentType())
+			if err := registry.Expose(w, format); err != nil {
+				logger.ErrorContext(r.Context(), "write /metrics response", "error", err)
+			}
 		}),
 	))
 
 	return middleware.Chain(
 		mux,
-		func(h http.Handler) http.Handler { return middleware.Logger(logger, h) },
-		func(h http.Handler) http.Handler { return middleware.Recover(logger, h) },
+		middleware.RequestID,
+		middleware.TraceContext,
+		func(h http.Handler) http.Handler { return middleware.AccessLog(logger, h) },
 		func(h http.Handler) http.Handler { return middleware.RequestCounter(counters, h) },
+		func(h http.Handler) http.Handler { return middleware.Recover(logger, h) },
+		func(h http.Handler) http.Handler { return middleware.Latency(counters, h) },
 	)
 }
 
-func runUptimeTicker(ctx context.Context, logger *log.Logger, counters *metrics.Counters, startedAt time.Time) {
+// metricsJSON is the /metrics?format=json view: every series, plus the
+// windowed per-second rates of metered counters keyed by span.
+type metricsJSON struct {
+	Metrics map[string]float64            `json:"metrics"`
+	Rates   map[string]map[string]float64 `json:"rates"`
+}
+
+func writeMetricsJSON(w http.ResponseWriter, r *http.Request, logger *slog.Logger, registry *metrics.Registry, counters *metrics.Counters) {
+	samples := registry.Samples()
+	for series, value := range samples {
+		// Empty summaries report NaN quantiles, which JSON cannot encode.
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			delete(samples, series)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(metricsJSON{Metrics: samples, Rates: counters.Rates()}); err != nil {
+		logger.ErrorContext(r.Context(), "encode /metrics JSON", "error", err)
+	}
+}
+
+func runUptimeTicker(ctx context.Context, logger *slog.Logger, counters *metrics.Counters, startedAt time.Time) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -135,7 +240,7 @@ func runUptimeTicker(ctx context.Context, logger *log.Logger, counters *metrics.
 		case now := <-ticker.C:
 			tick := counters.Inc("uptime_ticks")
 			uptime := now.Sub(startedAt).Round(time.Second)
-			logger.Printf("uptime tick #%d (%s)", tick, uptime)
+			logger.Info("uptime tick", "tick", tick, "uptime", uptime)
 		}
 	}
 }
@@ -3,20 +3,23 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/itprodirect/go-hello-world/internal/config"
+	"github.com/itprodirect/go-hello-world/internal/logging"
 	"github.com/itprodirect/go-hello-world/internal/metrics"
 )
 
 func newTestHandler() (http.Handler, config.AppConfig) {
 	cfg := config.DefaultConfig()
 	counters := metrics.NewCounters()
-	logger := log.New(&bytes.Buffer{}, "", 0)
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
 	return newHandler(cfg, logger, counters), cfg
 }
 
@@ -83,6 +86,29 @@ func TestNewHandlerHelloUsesDefaultNameWhenWhitespace(t *testing.T) {
 	}
 }
 
+func TestNewHandlerWritesJSONAccessLog(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.JSONOutput = true
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, cfg.LogLevel, cfg.JSONOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newHandler(cfg, logger, metrics.NewCounters())
+
+	req := httptest.NewRequest(http.MethodGet, "/hello?name=Nick", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("access log is not one JSON line: %v: %q", err, buf.String())
+	}
+	if entry["msg"] != "request" || entry["path"] != "/hello" || entry["status"] != float64(200) || entry["request_id"] != "abc" {
+		t.Fatalf("access log entry = %v", entry)
+	}
+}
+
 func TestNewHandlerHelloMethodNotAllowed(t *testing.T) {
 	h, _ := newTestHandler()
 
@@ -107,6 +133,31 @@ func TestNewHandlerHelloBadInput(t *testing.T) {
 	}
 }
 
+func TestNewHandlerPropagatesRequestAndTraceIDs(t *testing.T) {
+	h, _ := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/hello?name=<script>", nil)
+	req.Header.Set("X-Request-ID", "gw-123")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "gw=1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got != "gw-123" {
+		t.Fatalf("X-Request-ID = %q, want gw-123", got)
+	}
+	traceparent := rec.Header().Get("traceparent")
+	if !strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || strings.Contains(traceparent, "00f067aa0ba902b7") {
+		t.Fatalf("traceparent = %q, want the caller's trace with a new span", traceparent)
+	}
+	if got := rec.Header().Get("tracestate"); got != "gw=1" {
+		t.Fatalf("tracestate = %q, want gw=1", got)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "request_id=gw-123, trace_id=4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatalf("error body %q lacks the request and trace ids", body)
+	}
+}
+
 func TestNewHandlerHelloTooLongInput(t *testing.T) {
 	h, _ := newTestHandler()
 	longName := strings.Repeat("a", 51)
@@ -137,4 +188,133 @@ func TestNewHandlerMetricsEndpoint(t *testing.T) {
 	if !strings.Contains(body, "metrics_requests") {
 		t.Fatalf("metrics missing metrics_requests: %q", body)
 	}
+	if !strings.Contains(body, `http_responses_total{method="GET",path="/hello",status="200"} 1`) {
+		t.Fatalf("metrics missing labeled response counter: %q", body)
+	}
+	if !strings.Contains(body, `http_request_duration_seconds_bucket{le="+Inf"} 1`) {
+		t.Fatalf("metrics missing latency histogram: %q", body)
+	}
+	if !strings.Contains(body, "\ngo_goroutines ") {
+		t.Fatalf("metrics missing Go runtime collector: %q", body)
+	}
+	if !strings.Contains(body, "http_requests_in_flight 1\n") {
+		t.Fatalf("metrics missing in-flight gauge counting the scrape itself: %q", body)
+	}
+}
+
+func TestNewHandlerMetricsNegotiatesFormat(t *testing.T) {
+	h, _ := newTestHandler()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello?name=Nick", nil))
+
+	textRec := httptest.NewRecorder()
+	h.ServeHTTP(textRec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := textRec.Header().Get("Content-Type"); got != metrics.ContentTypeText {
+		t.Fatalf("default Content-Type = %q", got)
+	}
+	for _, want := range []string{
+		"# HELP hello_requests Greetings served by /hello.\n# TYPE hello_requests counter\nhello_requests 1\n",
+		"# TYPE http_request_duration_seconds histogram\n",
+	} {
+		if !strings.Contains(textRec.Body.String(), want) {
+			t.Fatalf("text metrics missing %q:\n%s", want, textRec.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5")
+	omRec := httptest.NewRecorder()
+	h.ServeHTTP(omRec, req)
+	if got := omRec.Header().Get("Content-Type"); got != metrics.ContentTypeOpenMetrics {
+		t.Fatalf("OpenMetrics Content-Type = %q", got)
+	}
+	body := omRec.Body.String()
+	if !strings.Contains(body, "# TYPE http_requests counter\nhttp_requests_total 3\n") || !strings.HasSuffix(body, "# EOF\n") {
+		t.Fatalf("unexpected OpenMetrics body:\n%s", body)
+	}
+}
+
+func TestNewHandlerMetricsJSON(t *testing.T) {
+	h, _ := newTestHandler()
+	for i := 0; i < 3; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello?name=Nick", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics?format=json", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("status=%d Content-Type=%q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var view metricsJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &view); err != nil {
+		t.Fatalf("decode: %v\n%s", err, rec.Body.String())
+	}
+	if view.Metrics["hello_requests"] != 3 || view.Metrics["go_goroutines"] < 1 {
+		t.Fatalf("metrics = %v", view.Metrics)
+	}
+	for _, name := range []string{"http_requests_total", "hello_requests"} {
+		for _, span := range []string{"1m", "5m", "15m"} {
+			if rate, ok := view.Rates[name][span]; !ok || rate <= 0 {
+				t.Errorf("rate %s[%s] = %v, %v; want a positive rate", name, span, rate, ok)
+			}
+		}
+	}
+
+	bad := httptest.NewRecorder()
+	h.ServeHTTP(bad, httptest.NewRequest(http.MethodGet, "/metrics?format=xml", nil))
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("format=xml status = %d, want 400", bad.Code)
+	}
+}
+
+func TestNewHandlerResponseSeriesAreCapped(t *testing.T) {
+	cfg := config.DefaultConfig()
+	counters := metrics.NewCounters()
+	h := newHandler(cfg, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), counters)
+
+	for i := 0; i < responseSeriesLimit+20; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/scan-%d", i), nil))
+	}
+
+	series := counters.MustCounterVec("http_responses_total", "method", "path", "status").Series()
+	if len(series) != responseSeriesLimit+1 {
+		t.Fatalf("series = %d, want %d plus overflow", len(series), responseSeriesLimit)
+	}
+	if overflow := series[len(series)-1]; overflow.Get() != 20 {
+		t.Fatalf("overflow series = %d, want 20", overflow.Get())
+	}
+}
+
+func TestSnapshotsPersistHelloCountAcrossRestarts(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SnapshotPath = filepath.Join(t.TempDir(), "counters.json")
+	cfg.PersistCounters = []string{"hello_requests"}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	hello := func(h http.Handler) helloResponse {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello?name=Nick", nil))
+		var resp helloResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp
+	}
+
+	first := metrics.NewCounters()
+	stop := startSnapshots(cfg, logger, first)
+	h := newHandler(cfg, logger, first)
+	hello(h)
+	hello(h)
+	stop()
+
+	second := metrics.NewCounters()
+	stop = startSnapshots(cfg, logger, second)
+	defer stop()
+	if resp := hello(newHandler(cfg, logger, second)); resp.Count != 3 {
+		t.Fatalf("count after restart = %d, want 3", resp.Count)
+	}
+	if got := second.Get("http_requests_total"); got != 1 {
+		t.Fatalf("http_requests_total = %d, want 1: only hello_requests persists", got)
+	}
 }
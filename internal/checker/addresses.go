@@ -0,0 +1,141 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Address policies decide the overall status of a per-address check.
+const (
+	AddressPolicyAll    = "all"
+	AddressPolicyAny    = "any"
+	AddressPolicyQuorum = "quorum"
+)
+
+// lookupIPs is the resolver used by per-address checks; tests replace it.
+var lookupIPs = func(ctx context.Context, network, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, network, host)
+}
+
+type probeFunc func(context.Context, Target) Result
+
+// checkAllAddresses resolves the target host and runs probe once per
+// address, pinning each run to its address with a resolve override.
+func checkAllAddresses(ctx context.Context, target Target, probe probeFunc) Result {
+	start := time.Now()
+	result := Result{
+		Name:   target.Name,
+		Type:   strings.ToLower(strings.TrimSpace(target.Type)),
+		Target: target.URL,
+	}
+	if result.Target == "" {
+		result.Target = net.JoinHostPort(target.Host, strconv.Itoa(target.Port))
+	}
+
+	fail := func(status, format string, args ...any) Result {
+		result.Status = status
+		result.Detail = fmt.Sprintf(format, args...)
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	policy := strings.ToLower(strings.TrimSpace(target.AddressPolicy))
+	switch policy {
+	case "":
+		policy = AddressPolicyAll
+	case AddressPolicyAll, AddressPolicyAny, AddressPolicyQuorum:
+	default:
+		return fail("error", "unknown address_policy %q", target.AddressPolicy)
+	}
+	if target.Proxy != "" {
+		return fail("error", "all_addresses cannot be combined with proxy")
+	}
+
+	host := target.Host
+	if target.URL != "" {
+		u, err := url.Parse(target.URL)
+		if err != nil {
+			return fail("error", "parse url: %v", err)
+		}
+		host = u.Hostname()
+	}
+
+	var ips []net.IP
+	switch {
+	case target.Resolve[host] != "":
+		ips = []net.IP{net.ParseIP(target.Resolve[host])}
+	case net.ParseIP(host) != nil:
+		ips = []net.IP{net.ParseIP(host)}
+	default:
+		resolved, err := lookupIPs(ctx, target.IPFamily.network("ip"), host)
+		if err != nil {
+			return fail("down", "resolve %s: %v", host, err)
+		}
+		ips = resolved
+	}
+	if len(ips) == 0 {
+		return fail("down", "resolve %s: no addresses", host)
+	}
+
+	result.Addresses = make([]Result, len(ips))
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		wg.Add(1)
+		go func(i int, ip net.IP) {
+			defer wg.Done()
+
+			pinned := target
+			pinned.AllAddresses = false
+			// Keep the other overrides, which redirects may still need.
+			pinned.Resolve = make(map[string]string, len(target.Resolve)+1)
+			maps.Copy(pinned.Resolve, target.Resolve)
+			if net.ParseIP(host) == nil {
+				pinned.Resolve[host] = ip.String()
+			}
+
+			sub := probe(ctx, pinned)
+			sub.Target = ip.String()
+			result.Addresses[i] = sub
+		}(i, ip)
+	}
+	wg.Wait()
+
+	up := 0
+	for _, sub := range result.Addresses {
+		if sub.Status == "up" {
+			up++
+			if result.TLS == nil {
+				result.TLS = sub.TLS
+			}
+		}
+		if sub.Latency > result.Latency {
+			result.Latency = sub.Latency
+		}
+	}
+
+	total := len(result.Addresses)
+	var ok bool
+	switch policy {
+	case AddressPolicyAll:
+		ok = up == total
+	case AddressPolicyAny:
+		ok = up > 0
+	case AddressPolicyQuorum:
+		ok = up*2 > total
+	}
+
+	if ok {
+		result.Status = "up"
+	} else {
+		result.Status = "down"
+	}
+	result.Detail = fmt.Sprintf("%d/%d addresses up (policy %s)", up, total, policy)
+	return result
+}
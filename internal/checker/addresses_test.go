@@ -0,0 +1,172 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func stubLookupIPs(t *testing.T, addrs ...string) {
+	t.Helper()
+
+	original := lookupIPs
+	t.Cleanup(func() { lookupIPs = original })
+
+	lookupIPs = func(ctx context.Context, network, host string) ([]net.IP, error) {
+		ips := make([]net.IP, 0, len(addrs))
+		for _, addr := range addrs {
+			ips = append(ips, net.ParseIP(addr))
+		}
+		return ips, nil
+	}
+}
+
+func TestCheckTCPAllAddressesPolicies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	// 127.0.0.2 is loopback on Linux but nothing listens there.
+	stubLookupIPs(t, "127.0.0.1", "127.0.0.2")
+
+	tests := []struct {
+		policy     string
+		wantStatus string
+	}{
+		{"", "down"},
+		{"all", "down"},
+		{"any", "up"},
+		{"quorum", "down"},
+	}
+
+	for _, tt := range tests {
+		result := Check(context.Background(), Target{
+			Name:          "dual",
+			Host:          "dual.invalid",
+			Port:          port,
+			Type:          "tcp",
+			Timeout:       500,
+			AllAddresses:  true,
+			AddressPolicy: tt.policy,
+		})
+
+		if result.Status != tt.wantStatus {
+			t.Errorf("policy %q: status=%q, want %q (detail=%s)", tt.policy, result.Status, tt.wantStatus, result.Detail)
+		}
+		if len(result.Addresses) != 2 {
+			t.Fatalf("policy %q: len(addresses)=%d, want 2", tt.policy, len(result.Addresses))
+		}
+		if result.Addresses[0].Target != "127.0.0.1" || result.Addresses[0].Status != "up" {
+			t.Errorf("policy %q: first address=%+v, want 127.0.0.1 up", tt.policy, result.Addresses[0])
+		}
+		if result.Addresses[1].Status != "down" {
+			t.Errorf("policy %q: second address status=%q, want down", tt.policy, result.Addresses[1].Status)
+		}
+		if !strings.HasPrefix(result.Detail, "1/2 addresses up") {
+			t.Errorf("policy %q: detail=%q", tt.policy, result.Detail)
+		}
+	}
+}
+
+func TestCheckHTTPAllAddressesQuorum(t *testing.T) {
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := first.Addr().(*net.TCPAddr).Port
+	second, err := net.Listen("tcp", net.JoinHostPort("127.0.0.2", strconv.Itoa(port)))
+	if err != nil {
+		first.Close()
+		t.Skipf("127.0.0.2 unavailable: %v", err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "web.invalid:"+strconv.Itoa(port) {
+			http.Error(w, "wrong host", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	for _, l := range []net.Listener{first, second} {
+		server := &httptest.Server{Listener: l, Config: &http.Server{Handler: handler}}
+		server.Start()
+		defer server.Close()
+	}
+
+	stubLookupIPs(t, "127.0.0.1", "127.0.0.2", "127.0.0.3")
+
+	result := Check(context.Background(), Target{
+		Name:          "web",
+		URL:           "http://web.invalid:" + strconv.Itoa(port) + "/",
+		Type:          "http",
+		Timeout:       500,
+		AllAddresses:  true,
+		AddressPolicy: "quorum",
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if result.Detail != "2/3 addresses up (policy quorum)" {
+		t.Fatalf("detail=%q", result.Detail)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"addresses":[{"name":"web","type":"http","target":"127.0.0.1","status":"up"`) {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
+
+func TestCheckAllAddressesInvalidPolicy(t *testing.T) {
+	result := Check(context.Background(), Target{
+		Name:          "bad-policy",
+		Host:          "127.0.0.1",
+		Port:          1,
+		Type:          "tcp",
+		AllAddresses:  true,
+		AddressPolicy: "most",
+	})
+
+	if result.Status != "error" || !strings.Contains(result.Detail, "address_policy") {
+		t.Fatalf("status=%q detail=%q, want address_policy error", result.Status, result.Detail)
+	}
+}
+
+func TestCheckHTTPAllAddressesKeepsOtherResolveOverrides(t *testing.T) {
+	var port string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Host, "primary.invalid:") {
+			http.Redirect(w, r, "http://other.invalid:"+port+"/ok", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	port = strconv.Itoa(server.Listener.Addr().(*net.TCPAddr).Port)
+
+	stubLookupIPs(t, "127.0.0.1")
+	resolve := map[string]string{"other.invalid": "127.0.0.1"}
+
+	result := Check(context.Background(), Target{
+		Name:            "redirecting",
+		URL:             "http://primary.invalid:" + port + "/",
+		Type:            "http",
+		Timeout:         500,
+		FollowRedirects: true,
+		AllAddresses:    true,
+		Resolve:         resolve,
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up via the other.invalid override (detail=%s)", result.Status, result.Detail)
+	}
+	if len(resolve) != 1 {
+		t.Fatalf("target resolve map was modified: %v", resolve)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -34,8 +36,53 @@ type Target struct {
 	URL     string `json:"url,omitempty"`
 	Host    string `json:"host,omitempty"`
 	Port    int    `json:"port,omitempty"`
-	Type    string `json:"type"` // http, tcp, dns
+	Type    string `json:"type"` // http, tcp, dns, websocket, sse, flow
 	Timeout int    `json:"timeout_ms,omitempty"`
+
+	// Send is a message written after a websocket upgrade.
+	Send string `json:"send,omitempty"`
+	// Expect is a substring a websocket message or SSE event must contain.
+	Expect string `json:"expect,omitempty"`
+	// ExpectEvents is the number of SSE events to wait for (default 1).
+	ExpectEvents int `json:"expect_events,omitempty"`
+
+	// Steps are the HTTP requests of a flow check, run in order.
+	Steps []FlowStep `json:"steps,omitempty"`
+
+	// FollowRedirects makes http checks follow 3xx responses, up to
+	// MaxRedirects hops (default 10), and judge the final response.
+	FollowRedirects bool `json:"follow_redirects,omitempty"`
+	MaxRedirects    int  `json:"max_redirects,omitempty"`
+	// ExpectFinalURL is the URL the http check must end up on.
+	ExpectFinalURL string `json:"expect_final_url,omitempty"`
+	// Content marks an http check down when the body changes between runs.
+	Content *ContentCheck `json:"content,omitempty"`
+
+	// TLSConfig sets client certificates, CA bundle, SNI and protocol
+	// limits for HTTP-based checks and the tcp certificate probe.
+	TLSConfig *TLSOptions `json:"tls,omitempty"`
+
+	// Proxy routes HTTP checks through the given proxy URL and tunnels tcp
+	// checks through it with HTTP CONNECT.
+	Proxy string `json:"proxy,omitempty"`
+	// IPFamily pins dialling (and dns lookups) to IPv4 or IPv6.
+	IPFamily IPFamily `json:"ip_family,omitempty"`
+	// LocalAddr is the source IP address outgoing connections bind to.
+	LocalAddr string `json:"local_addr,omitempty"`
+	// Resolve maps hostnames to fixed IPs, like curl --resolve.
+	Resolve map[string]string `json:"resolve,omitempty"`
+
+	// AllAddresses makes http and tcp checks probe every resolved address
+	// individually. AddressPolicy (all, any, quorum; default all) decides
+	// how many must be up for the target to be up.
+	AllAddresses  bool   `json:"all_addresses,omitempty"`
+	AddressPolicy string `json:"address_policy,omitempty"`
+
+	// SLO declares objectives evaluated against recorded check history.
+	SLO *SLO `json:"slo,omitempty"`
+
+	// Priority orders checks when workers are scarce; higher runs first.
+	Priority int `json:"priority,omitempty"`
 }
 
 // Result is the outcome of a single check.
@@ -47,6 +94,11 @@ type Result struct {
 	Latency time.Duration `json:"-"`
 	Detail  string        `json:"detail,omitempty"`
 	TLS     *TLSInfo      `json:"tls,omitempty"`
+	Steps   []StepResult  `json:"steps,omitempty"`
+	// Redirects is the hop chain of an http check that followed redirects.
+	Redirects []RedirectHop `json:"redirects,omitempty"`
+	// Addresses holds per-address results when AllAddresses is set.
+	Addresses []Result `json:"addresses,omitempty"`
 }
 
 // TLSInfo contains peer certificate summary data.
@@ -69,11 +121,23 @@ func Check(ctx context.Context, target Target) Result {
 
 	switch strings.ToLower(strings.TrimSpace(target.Type)) {
 	case "http":
+		if target.AllAddresses {
+			return checkAllAddresses(checkCtx, target, checkHTTP)
+		}
 		return checkHTTP(checkCtx, target)
 	case "tcp":
+		if target.AllAddresses {
+			return checkAllAddresses(checkCtx, target, checkTCP)
+		}
 		return checkTCP(checkCtx, target)
 	case "dns":
 		return checkDNS(checkCtx, target)
+	case "websocket", "ws":
+		return checkWebSocket(checkCtx, target)
+	case "sse":
+		return checkSSE(checkCtx, target)
+	case "flow":
+		return checkFlow(checkCtx, target)
 	default:
 		return Result{
 			Name:   target.Name,
@@ -101,7 +165,24 @@ func checkHTTP(ctx context.Context, target Target) Result {
 		return result
 	}
 
-	resp, err := sharedHTTPClient.Do(req)
+	client, err := httpClientFor(target)
+	if err != nil {
+		result.Status = "error"
+		result.Detail = err.Error()
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	var resp *http.Response
+	if target.FollowRedirects {
+		maxHops := target.MaxRedirects
+		if maxHops <= 0 {
+			maxHops = defaultMaxRedirects
+		}
+		resp, result.Redirects, err = followRedirects(client, req, maxHops)
+	} else {
+		resp, err = client.Do(req)
+	}
 	result.Latency = time.Since(start)
 	if err != nil {
 		result.Status = "down"
@@ -116,31 +197,62 @@ func checkHTTP(ctx context.Context, target Target) Result {
 		result.Status = "down"
 	}
 	result.Detail = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	if redirects := len(result.Redirects) - 1; redirects > 0 {
+		result.Detail = fmt.Sprintf("HTTP %d after %d redirects", resp.StatusCode, redirects)
+	}
 
-	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
-		cert := resp.TLS.PeerCertificates[0]
-		result.TLS = &TLSInfo{
-			Subject:  cert.Subject.CommonName,
-			Issuer:   cert.Issuer.CommonName,
-			NotAfter: cert.NotAfter,
-			DaysLeft: int(time.Until(cert.NotAfter).Hours() / 24),
+	if target.ExpectFinalURL != "" {
+		finalURL := resp.Request.URL.String()
+		if !sameURL(finalURL, target.ExpectFinalURL) {
+			result.Status = "down"
+			result.Detail = fmt.Sprintf("%s; final URL %s, want %s", result.Detail, finalURL, target.ExpectFinalURL)
 		}
 	}
 
+	if target.Content != nil && result.Status == "up" && resp.StatusCode < 300 {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxContentBody))
+		if err != nil {
+			result.Status = "down"
+			result.Detail = fmt.Sprintf("%s; read body: %v", result.Detail, err)
+			return result
+		}
+
+		changed, detail, err := target.Content.compare(target, body)
+		switch {
+		case err != nil:
+			result.Status = "error"
+			result.Detail = fmt.Sprintf("%s; %v", result.Detail, err)
+		case changed:
+			result.Status = "down"
+			result.Detail = fmt.Sprintf("%s; %s", result.Detail, detail)
+		default:
+			result.Detail = fmt.Sprintf("%s; %s", result.Detail, detail)
+		}
+	}
+
+	result.TLS = tlsInfo(resp.TLS)
+
 	return result
 }
 
 func checkTCP(ctx context.Context, target Target) Result {
 	start := time.Now()
-	addr := fmt.Sprintf("%s:%d", target.Host, target.Port)
+	addr := net.JoinHostPort(target.Host, strconv.Itoa(target.Port))
 	result := Result{
 		Name:   target.Name,
 		Type:   "tcp",
 		Target: addr,
 	}
 
-	var dialer net.Dialer
-	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	dialer, err := newTargetDialer(target)
+	if err != nil {
+		result.Status = "error"
+		result.Detail = err.Error()
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	conn, err := dialer.DialTarget(ctx, addr)
 	result.Latency = time.Since(start)
 	if err != nil {
 		result.Status = "down"
@@ -153,33 +265,50 @@ func checkTCP(ctx context.Context, target Target) Result {
 	result.Detail = "connection successful"
 
 	if target.Port == 443 || target.Port == 8443 {
-		tlsConn, err := (&tls.Dialer{
-			NetDialer: &net.Dialer{},
-			Config: &tls.Config{
-				InsecureSkipVerify: true, //nolint:gosec // probe tool intentionally accepts unknown certs
-			},
-		}).DialContext(ctx, "tcp", addr)
-		if err == nil {
-			defer tlsConn.Close()
-			tlsClient, ok := tlsConn.(*tls.Conn)
-			if ok {
-				state := tlsClient.ConnectionState()
-				if len(state.PeerCertificates) > 0 {
-					cert := state.PeerCertificates[0]
-					result.TLS = &TLSInfo{
-						Subject:  cert.Subject.CommonName,
-						Issuer:   cert.Issuer.CommonName,
-						NotAfter: cert.NotAfter,
-						DaysLeft: int(time.Until(cert.NotAfter).Hours() / 24),
-					}
-				}
-			}
-		}
+		result.TLS = probeTLS(ctx, dialer, target, addr)
 	}
 
 	return result
 }
 
+// probeTLS performs a TLS handshake over a fresh connection and returns
+// the peer certificate summary, or nil if the handshake fails.
+func probeTLS(ctx context.Context, dialer *targetDialer, target Target, addr string) *TLSInfo {
+	conn, err := dialer.DialTarget(ctx, addr)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	cfg := probeTLSConfig(target)
+	if cfg.ServerName == "" {
+		cfg.ServerName = target.Host
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil
+	}
+
+	state := tlsConn.ConnectionState()
+	return tlsInfo(&state)
+}
+
+// tlsInfo summarises the leaf certificate of a TLS connection, if any.
+func tlsInfo(state *tls.ConnectionState) *TLSInfo {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := state.PeerCertificates[0]
+	return &TLSInfo{
+		Subject:  cert.Subject.CommonName,
+		Issuer:   cert.Issuer.CommonName,
+		NotAfter: cert.NotAfter,
+		DaysLeft: int(time.Until(cert.NotAfter).Hours() / 24),
+	}
+}
+
 func checkDNS(ctx context.Context, target Target) Result {
 	start := time.Now()
 	result := Result{
@@ -189,7 +318,17 @@ func checkDNS(ctx context.Context, target Target) Result {
 	}
 
 	resolver := &net.Resolver{}
-	addrs, err := resolver.LookupHost(ctx, target.Host)
+	var addrs []string
+	var err error
+	if target.IPFamily == IPFamilyAny {
+		addrs, err = resolver.LookupHost(ctx, target.Host)
+	} else {
+		var ips []net.IP
+		ips, err = resolver.LookupIP(ctx, target.IPFamily.network("ip"), target.Host)
+		for _, ip := range ips {
+			addrs = append(addrs, ip.String())
+		}
+	}
 	result.Latency = time.Since(start)
 	if err != nil {
 		result.Status = "down"
@@ -202,7 +341,8 @@ func checkDNS(ctx context.Context, target Target) Result {
 	return result
 }
 
-// LoadTargets loads targets from a JSON file.
+// LoadTargets loads targets from a JSON file and rejects option
+// combinations that cannot be checked.
 func LoadTargets(path string) ([]Target, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -214,19 +354,30 @@ func LoadTargets(path string) ([]Target, error) {
 		return nil, fmt.Errorf("parse targets file: %w", err)
 	}
 
+	for _, target := range targets {
+		// Each address would compare against the same stored body, so one
+		// address could hide a change and concurrent writes could race.
+		if target.Content != nil && target.AllAddresses {
+			return nil, fmt.Errorf("target %q: content cannot be combined with all_addresses", target.Name)
+		}
+	}
+
 	return targets, nil
 }
 
 // MarshalJSON renders Latency as integer milliseconds under latency_ms.
 func (r Result) MarshalJSON() ([]byte, error) {
 	type resultJSON struct {
-		Name      string   `json:"name"`
-		Type      string   `json:"type"`
-		Target    string   `json:"target"`
-		Status    string   `json:"status"`
-		LatencyMS int64    `json:"latency_ms"`
-		Detail    string   `json:"detail,omitempty"`
-		TLS       *TLSInfo `json:"tls,omitempty"`
+		Name      string        `json:"name"`
+		Type      string        `json:"type"`
+		Target    string        `json:"target"`
+		Status    string        `json:"status"`
+		LatencyMS int64         `json:"latency_ms"`
+		Detail    string        `json:"detail,omitempty"`
+		TLS       *TLSInfo      `json:"tls,omitempty"`
+		Steps     []StepResult  `json:"steps,omitempty"`
+		Redirects []RedirectHop `json:"redirects,omitempty"`
+		Addresses []Result      `json:"addresses,omitempty"`
 	}
 
 	return json.Marshal(resultJSON{
@@ -237,6 +388,9 @@ func (r Result) MarshalJSON() ([]byte, error) {
 		LatencyMS: r.Latency.Milliseconds(),
 		Detail:    r.Detail,
 		TLS:       r.TLS,
+		Steps:     r.Steps,
+		Redirects: r.Redirects,
+		Addresses: r.Addresses,
 	})
 }
 
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -236,6 +237,19 @@ func TestLoadTargets(t *testing.T) {
 	}
 }
 
+func TestLoadTargetsRejectsContentWithAllAddresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.json")
+	data := []byte(`[{"name":"site","url":"https://example.com","type":"http","all_addresses":true,"content":{}}]`)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write targets: %v", err)
+	}
+
+	_, err := LoadTargets(path)
+	if err == nil || !strings.Contains(err.Error(), "all_addresses") {
+		t.Fatalf("LoadTargets() error = %v, want content/all_addresses rejection", err)
+	}
+}
+
 func TestStatusEmoji(t *testing.T) {
 	tests := []struct {
 		status string
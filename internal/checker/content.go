@@ -0,0 +1,250 @@
+package checker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxContentBody bounds how much of a response is hashed and diffed.
+const maxContentBody = 1 << 20
+
+// Diff snippet limits keep Result.Detail readable.
+const (
+	diffContextLines = 2
+	maxDiffLines     = 20
+	maxDiffCells     = 1 << 20
+)
+
+// ContentCheck makes an http check compare the response body with the
+// body seen on the previous run.
+type ContentCheck struct {
+	// Ignore lists regular expressions removed from the body before
+	// hashing, e.g. timestamps or nonces.
+	Ignore []string `json:"ignore,omitempty"`
+	// StateDir holds the stored bodies; it defaults to a directory under
+	// the user cache dir.
+	StateDir string `json:"state_dir,omitempty"`
+}
+
+type contentState struct {
+	Hash      string    `json:"hash"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// compare normalises body, compares it with the stored state for target
+// and records it as the new baseline. It reports whether the content
+// changed along with a detail message.
+func (c *ContentCheck) compare(target Target, body []byte) (bool, string, error) {
+	normalized, err := c.normalize(body)
+	if err != nil {
+		return false, "", err
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	hash := hex.EncodeToString(sum[:])
+
+	path := c.statePath(target)
+	previous, err := loadContentState(path)
+	if err != nil {
+		return false, "", err
+	}
+
+	if previous != nil && previous.Hash == hash {
+		return false, "content unchanged", nil
+	}
+
+	if err := saveContentState(path, contentState{Hash: hash, Body: normalized, UpdatedAt: time.Now().UTC()}); err != nil {
+		return false, "", err
+	}
+
+	if previous == nil {
+		return false, fmt.Sprintf("content baseline recorded (%s)", hash[:12]), nil
+	}
+
+	detail := fmt.Sprintf("content changed (%s -> %s)", previous.Hash[:12], hash[:12])
+	if diff := unifiedDiff(previous.Body, normalized); diff != "" {
+		detail += "\n" + diff
+	}
+	return true, detail, nil
+}
+
+// normalize strips ignored patterns, unifies line endings and trims
+// trailing whitespace so cosmetic noise does not count as a change.
+func (c *ContentCheck) normalize(body []byte) (string, error) {
+	text := strings.ReplaceAll(string(body), "\r\n", "\n")
+
+	for _, pattern := range c.Ignore {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("content ignore pattern %q: %w", pattern, err)
+		}
+		text = re.ReplaceAllString(text, "")
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n"), nil
+}
+
+func (c *ContentCheck) statePath(target Target) string {
+	dir := c.StateDir
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			cacheDir = os.TempDir()
+		}
+		dir = filepath.Join(cacheDir, "go-hello-world", "content")
+	}
+
+	key := sha256.Sum256([]byte(target.Name + "\x00" + target.URL))
+	return filepath.Join(dir, hex.EncodeToString(key[:8])+".json")
+}
+
+func loadContentState(path string) (*contentState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read content state: %w", err)
+	}
+
+	var state contentState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse content state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// saveContentState writes state atomically via a temp file and rename.
+func saveContentState(path string, state contentState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create content state dir: %w", err)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".content-*")
+	if err != nil {
+		return fmt.Errorf("write content state: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write content state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write content state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write content state: %w", err)
+	}
+	return nil
+}
+
+// unifiedDiff renders a short unified-style diff of the changed region.
+// Very large changes fall back to listing removed and added lines.
+func unifiedDiff(before, after string) string {
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	var body []string
+	if len(midA)*len(midB) <= maxDiffCells {
+		body = diffLines(midA, midB)
+	} else {
+		for _, line := range midA {
+			body = append(body, "-"+line)
+		}
+		for _, line := range midB {
+			body = append(body, "+"+line)
+		}
+	}
+
+	ctxStart := max(0, prefix-diffContextLines)
+	ctxEnd := min(len(a), len(a)-suffix+diffContextLines)
+
+	lines := []string{fmt.Sprintf("@@ -%d,%d +%d,%d @@",
+		ctxStart+1, ctxEnd-ctxStart,
+		ctxStart+1, ctxEnd-ctxStart+len(midB)-len(midA))}
+	for _, line := range a[ctxStart:prefix] {
+		lines = append(lines, " "+line)
+	}
+	lines = append(lines, body...)
+	for _, line := range a[len(a)-suffix : ctxEnd] {
+		lines = append(lines, " "+line)
+	}
+
+	if len(lines) > maxDiffLines+1 {
+		omitted := len(lines) - maxDiffLines - 1
+		lines = append(lines[:maxDiffLines+1], fmt.Sprintf("... (%d more lines)", omitted))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diffLines produces -/+/space prefixed lines from an LCS alignment.
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+"+b[j])
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckHTTPContentChangeDetection(t *testing.T) {
+	var version atomic.Int32
+	var nonce atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := nonce.Add(1)
+		fmt.Fprintf(w, "<html>\r\n<h1>Status</h1>\r\n<p>generated at 2026-03-0%dT10:00:00Z nonce=%d</p>\r\n", n, n)
+		fmt.Fprintf(w, "<p>version %d</p>\r\n<footer>ok</footer>\r\n", version.Load())
+	}))
+	defer server.Close()
+
+	target := Target{
+		Name: "status-page",
+		URL:  server.URL,
+		Type: "http",
+		Content: &ContentCheck{
+			StateDir: t.TempDir(),
+			Ignore:   []string{`\d{4}-\d{2}-\d{2}T[0-9:]+Z`, `nonce=\d+`},
+		},
+	}
+
+	first := Check(context.Background(), target)
+	if first.Status != "up" || !strings.Contains(first.Detail, "content baseline recorded") {
+		t.Fatalf("first run: status=%q detail=%q", first.Status, first.Detail)
+	}
+
+	second := Check(context.Background(), target)
+	if second.Status != "up" || second.Detail != "HTTP 200; content unchanged" {
+		t.Fatalf("second run: status=%q detail=%q", second.Status, second.Detail)
+	}
+
+	version.Store(2)
+	third := Check(context.Background(), target)
+	if third.Status != "down" {
+		t.Fatalf("third run: status=%q, want down (detail=%s)", third.Status, third.Detail)
+	}
+	for _, want := range []string{"content changed", "-<p>version 0</p>", "+<p>version 2</p>", " <footer>ok</footer>"} {
+		if !strings.Contains(third.Detail, want) {
+			t.Errorf("third run detail missing %q:\n%s", want, third.Detail)
+		}
+	}
+
+	fourth := Check(context.Background(), target)
+	if fourth.Status != "up" {
+		t.Fatalf("fourth run: status=%q, want up once the new baseline is stored", fourth.Status)
+	}
+}
+
+func TestCheckHTTPContentInvalidIgnorePattern(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:    "bad-pattern",
+		URL:     server.URL,
+		Type:    "http",
+		Content: &ContentCheck{StateDir: t.TempDir(), Ignore: []string{"("}},
+	})
+
+	if result.Status != "error" || !strings.Contains(result.Detail, "content ignore pattern") {
+		t.Fatalf("status=%q detail=%q, want ignore pattern error", result.Status, result.Detail)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf"
+	after := "a\nb\nc\nX\ne\nf\ng"
+
+	got := unifiedDiff(before, after)
+	want := strings.Join([]string{
+		"@@ -2,5 +2,6 @@",
+		" b",
+		" c",
+		"-d",
+		"+X",
+		" e",
+		" f",
+		"+g",
+	}, "\n")
+	if got != want {
+		t.Fatalf("unifiedDiff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffTruncatesLongChanges(t *testing.T) {
+	var before, after []string
+	for i := 0; i < 50; i++ {
+		before = append(before, fmt.Sprintf("old %d", i))
+		after = append(after, fmt.Sprintf("new %d", i))
+	}
+
+	got := unifiedDiff(strings.Join(before, "\n"), strings.Join(after, "\n"))
+	lines := strings.Split(got, "\n")
+	if len(lines) != maxDiffLines+2 {
+		t.Fatalf("len(lines)=%d, want %d", len(lines), maxDiffLines+2)
+	}
+	if !strings.HasPrefix(lines[len(lines)-1], "... (") {
+		t.Fatalf("last line=%q, want truncation marker", lines[len(lines)-1])
+	}
+}
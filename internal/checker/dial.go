@@ -0,0 +1,190 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IPFamily restricts which address family a probe dials.
+type IPFamily string
+
+const (
+	IPFamilyAny IPFamily = ""
+	IPFamily4   IPFamily = "4"
+	IPFamily6   IPFamily = "6"
+)
+
+// UnmarshalJSON accepts 4, 6, "4", "6", "any" or an empty string.
+func (f *IPFamily) UnmarshalJSON(data []byte) error {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var s string
+	switch v := raw.(type) {
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		s = strings.ToLower(strings.TrimSpace(v))
+	case nil:
+	default:
+		return fmt.Errorf("invalid ip_family %s", data)
+	}
+
+	switch s {
+	case "", "any":
+		*f = IPFamilyAny
+	case "4", "ipv4":
+		*f = IPFamily4
+	case "6", "ipv6":
+		*f = IPFamily6
+	default:
+		return fmt.Errorf("invalid ip_family %q (want 4, 6 or any)", s)
+	}
+	return nil
+}
+
+// network narrows a generic "tcp" or "ip" network to the selected family.
+func (f IPFamily) network(base string) string {
+	switch f {
+	case IPFamily4:
+		return base + "4"
+	case IPFamily6:
+		return base + "6"
+	default:
+		return base
+	}
+}
+
+// targetDialer opens connections honouring a target's egress settings.
+type targetDialer struct {
+	family  IPFamily
+	dialer  *net.Dialer
+	resolve map[string]string
+	proxy   *url.URL
+}
+
+func newTargetDialer(target Target) (*targetDialer, error) {
+	d := &targetDialer{
+		family:  target.IPFamily,
+		dialer:  &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second},
+		resolve: target.Resolve,
+	}
+
+	if target.LocalAddr != "" {
+		ip := net.ParseIP(target.LocalAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid local_addr %q", target.LocalAddr)
+		}
+		d.dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	for host, addr := range target.Resolve {
+		if net.ParseIP(addr) == nil {
+			return nil, fmt.Errorf("invalid resolve address %q for %s", addr, host)
+		}
+	}
+
+	if target.Proxy != "" {
+		proxyURL, err := url.Parse(target.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", target.Proxy)
+		}
+		d.proxy = proxyURL
+	}
+
+	return d, nil
+}
+
+// DialContext dials addr directly, applying resolve overrides, the IP
+// family and the local bind address. It never uses the proxy.
+func (d *targetDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if override, ok := d.resolve[host]; ok {
+		addr = net.JoinHostPort(override, port)
+	}
+	if network == "tcp" {
+		network = d.family.network("tcp")
+	}
+	return d.dialer.DialContext(ctx, network, addr)
+}
+
+// DialTarget opens a TCP connection to addr, tunnelling through the
+// proxy with HTTP CONNECT when one is configured.
+func (d *targetDialer) DialTarget(ctx context.Context, addr string) (net.Conn, error) {
+	if d.proxy == nil {
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	if d.proxy.Scheme != "http" {
+		return nil, fmt.Errorf("tcp tunnelling supports only http:// proxies, got %q", d.proxy.Scheme)
+	}
+
+	proxyAddr := d.proxy.Host
+	if d.proxy.Port() == "" {
+		proxyAddr = net.JoinHostPort(d.proxy.Hostname(), "80")
+	}
+
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("dial proxy: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := d.proxy.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT: %s", resp.Status)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return &bufferedConn{Conn: conn, r: br}, nil
+}
+
+// bufferedConn replays bytes the proxy sent after its CONNECT response.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,235 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func listenerPort(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("SplitHostPort: %v", err)
+	}
+	return port
+}
+
+func TestCheckHTTPResolveOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Host, "service.invalid:") {
+			http.Error(w, "wrong host", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:    "resolve",
+		URL:     "http://service.invalid:" + listenerPort(t, server) + "/",
+		Type:    "http",
+		Resolve: map[string]string{"service.invalid": "127.0.0.1"},
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+}
+
+func TestCheckHTTPLocalAddr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		if host != "127.0.0.1" {
+			http.Error(w, "unexpected source "+host, http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:      "bind",
+		URL:       server.URL,
+		Type:      "http",
+		LocalAddr: "127.0.0.1",
+		IPFamily:  IPFamily4,
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+}
+
+func TestCheckHTTPViaProxy(t *testing.T) {
+	var seen string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	result := Check(context.Background(), Target{
+		Name:  "proxied",
+		URL:   "http://upstream.invalid/status",
+		Type:  "http",
+		Proxy: proxy.URL,
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if seen != "http://upstream.invalid/status" {
+		t.Fatalf("proxy saw %q, want absolute upstream URL", seen)
+	}
+}
+
+func TestCheckTCPViaConnectProxy(t *testing.T) {
+	backend, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer backend.Close()
+	go func() {
+		for {
+			conn, err := backend.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	var mu sync.Mutex
+	var tunnelled string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		mu.Lock()
+		tunnelled = r.Host
+		mu.Unlock()
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 Connection established\r\n\r\n")
+		_ = rw.Flush()
+		_, _ = io.Copy(conn, upstream)
+	}))
+	defer proxy.Close()
+
+	port := backend.Addr().(*net.TCPAddr).Port
+	result := Check(context.Background(), Target{
+		Name:  "tunnel",
+		Host:  "127.0.0.1",
+		Port:  port,
+		Type:  "tcp",
+		Proxy: proxy.URL,
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if tunnelled != backend.Addr().String() {
+		t.Fatalf("proxy tunnelled %q, want %q", tunnelled, backend.Addr().String())
+	}
+}
+
+func TestCheckTCPConnectProxyRefused(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer proxy.Close()
+
+	result := Check(context.Background(), Target{
+		Name:  "denied",
+		Host:  "127.0.0.1",
+		Port:  9,
+		Type:  "tcp",
+		Proxy: proxy.URL,
+	})
+
+	if result.Status != "down" || !strings.Contains(result.Detail, "403") {
+		t.Fatalf("status=%q detail=%q, want down with 403", result.Status, result.Detail)
+	}
+}
+
+func TestCheckTCPIPFamilyMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:     "v6-only",
+		Host:     "127.0.0.1",
+		Port:     server.Listener.Addr().(*net.TCPAddr).Port,
+		Type:     "tcp",
+		IPFamily: IPFamily6,
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down for IPv4 literal with ip_family 6", result.Status)
+	}
+}
+
+func TestTargetDialerInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Target
+	}{
+		{"local addr", Target{LocalAddr: "not-an-ip"}},
+		{"resolve", Target{Resolve: map[string]string{"a.test": "nope"}}},
+		{"proxy", Target{Proxy: "://bad"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := newTargetDialer(tt.target); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestIPFamilyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    IPFamily
+		wantErr bool
+	}{
+		{`4`, IPFamily4, false},
+		{`"6"`, IPFamily6, false},
+		{`"any"`, IPFamilyAny, false},
+		{`"IPv4"`, IPFamily4, false},
+		{`5`, "", true},
+		{`true`, "", true},
+	}
+
+	for _, tt := range tests {
+		var got IPFamily
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) err=%v, wantErr=%v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFlowBody bounds how much of each step response is kept for
+// assertions and extraction.
+const maxFlowBody = 1 << 20
+
+var flowVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// FlowStep is one HTTP request within a synthetic transaction.
+//
+// URL, Headers and Body may reference variables extracted by earlier steps
+// as {{name}}. Extract maps a variable name to a source of the form
+// "json:<dotted.path>", "header:<Name>" or "cookie:<name>".
+type FlowStep struct {
+	Name         string            `json:"name"`
+	Method       string            `json:"method,omitempty"`
+	URL          string            `json:"url"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	ExpectStatus int               `json:"expect_status,omitempty"`
+	ExpectBody   string            `json:"expect_body,omitempty"`
+	Extract      map[string]string `json:"extract,omitempty"`
+}
+
+// StepResult is the outcome of one flow step.
+type StepResult struct {
+	Name       string        `json:"name"`
+	Status     string        `json:"status"` // up, down, error
+	HTTPStatus int           `json:"http_status,omitempty"`
+	Latency    time.Duration `json:"-"`
+	Detail     string        `json:"detail,omitempty"`
+}
+
+// MarshalJSON renders Latency as integer milliseconds under latency_ms.
+func (s StepResult) MarshalJSON() ([]byte, error) {
+	type stepJSON struct {
+		Name       string `json:"name"`
+		Status     string `json:"status"`
+		HTTPStatus int    `json:"http_status,omitempty"`
+		LatencyMS  int64  `json:"latency_ms"`
+		Detail     string `json:"detail,omitempty"`
+	}
+
+	return json.Marshal(stepJSON{
+		Name:       s.Name,
+		Status:     s.Status,
+		HTTPStatus: s.HTTPStatus,
+		LatencyMS:  s.Latency.Milliseconds(),
+		Detail:     s.Detail,
+	})
+}
+
+// checkFlow runs target.Steps in order with a fresh cookie jar, stopping
+// at the first failing step.
+func checkFlow(ctx context.Context, target Target) Result {
+	start := time.Now()
+	result := Result{
+		Name:   target.Name,
+		Type:   "flow",
+		Target: target.URL,
+	}
+	if result.Target == "" && len(target.Steps) > 0 {
+		result.Target = target.Steps[0].URL
+	}
+
+	if len(target.Steps) == 0 {
+		result.Status = "error"
+		result.Detail = "flow has no steps"
+		return result
+	}
+
+	base, err := httpClientFor(target)
+	if err != nil {
+		result.Status = "error"
+		result.Detail = err.Error()
+		return result
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		result.Status = "error"
+		result.Detail = fmt.Sprintf("create cookie jar: %v", err)
+		return result
+	}
+	client := &http.Client{
+		Transport:     base.Transport,
+		CheckRedirect: base.CheckRedirect,
+		Jar:           jar,
+	}
+
+	vars := make(map[string]string)
+	for i, step := range target.Steps {
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step-%d", i+1)
+		}
+
+		stepResult := runFlowStep(ctx, client, step, vars)
+		result.Steps = append(result.Steps, stepResult)
+		if stepResult.Status != "up" {
+			result.Status = stepResult.Status
+			result.Detail = fmt.Sprintf("step %q: %s", stepResult.Name, stepResult.Detail)
+			result.Latency = time.Since(start)
+			return result
+		}
+	}
+
+	result.Status = "up"
+	result.Detail = fmt.Sprintf("%d/%d steps passed", len(target.Steps), len(target.Steps))
+	result.Latency = time.Since(start)
+	return result
+}
+
+func runFlowStep(ctx context.Context, client *http.Client, step FlowStep, vars map[string]string) StepResult {
+	start := time.Now()
+	stepResult := StepResult{Name: step.Name}
+
+	fail := func(status, format string, args ...any) StepResult {
+		stepResult.Status = status
+		stepResult.Detail = fmt.Sprintf(format, args...)
+		stepResult.Latency = time.Since(start)
+		return stepResult
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(step.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	rawURL, err := expandFlowVars(step.URL, vars)
+	if err != nil {
+		return fail("error", "url: %v", err)
+	}
+	body, err := expandFlowVars(step.Body, vars)
+	if err != nil {
+		return fail("error", "body: %v", err)
+	}
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
+	if err != nil {
+		return fail("error", "build request: %v", err)
+	}
+	for name, value := range step.Headers {
+		expanded, err := expandFlowVars(value, vars)
+		if err != nil {
+			return fail("error", "header %s: %v", name, err)
+		}
+		req.Header.Set(name, expanded)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail("down", "%v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxFlowBody))
+	stepResult.Latency = time.Since(start)
+	stepResult.HTTPStatus = resp.StatusCode
+	if err != nil {
+		return fail("down", "read body: %v", err)
+	}
+
+	if step.ExpectStatus != 0 {
+		if resp.StatusCode != step.ExpectStatus {
+			return fail("down", "HTTP %d, want %d", resp.StatusCode, step.ExpectStatus)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fail("down", "HTTP %d", resp.StatusCode)
+	}
+
+	if step.ExpectBody != "" && !strings.Contains(string(respBody), step.ExpectBody) {
+		return fail("down", "body does not contain %q", step.ExpectBody)
+	}
+
+	for name, source := range step.Extract {
+		value, err := extractFlowVar(source, resp, respBody, client.Jar)
+		if err != nil {
+			return fail("down", "extract %s: %v", name, err)
+		}
+		vars[name] = value
+	}
+
+	stepResult.Status = "up"
+	stepResult.Detail = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	return stepResult
+}
+
+// expandFlowVars replaces {{name}} references with extracted values.
+func expandFlowVars(s string, vars map[string]string) (string, error) {
+	var missing []string
+	out := flowVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := flowVarPattern.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variable(s): %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+func extractFlowVar(source string, resp *http.Response, body []byte, jar http.CookieJar) (string, error) {
+	kind, key, ok := strings.Cut(source, ":")
+	if !ok || key == "" {
+		return "", fmt.Errorf("invalid source %q (want json:, header: or cookie:)", source)
+	}
+
+	switch strings.ToLower(kind) {
+	case "json":
+		doc, err := decodeJSON(body)
+		if err != nil {
+			return "", fmt.Errorf("parse JSON body: %w", err)
+		}
+		return lookupJSONPath(doc, key)
+	case "header":
+		value := resp.Header.Get(key)
+		if value == "" {
+			return "", fmt.Errorf("header %q not present", key)
+		}
+		return value, nil
+	case "cookie":
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == key {
+				return cookie.Value, nil
+			}
+		}
+		for _, cookie := range jar.Cookies(resp.Request.URL) {
+			if cookie.Name == key {
+				return cookie.Value, nil
+			}
+		}
+		return "", fmt.Errorf("cookie %q not set", key)
+	default:
+		return "", fmt.Errorf("unknown source kind %q", kind)
+	}
+}
+
+// decodeJSON parses a whole JSON document, keeping numbers as json.Number
+// so large integer IDs survive unchanged.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return doc, nil
+}
+
+// lookupJSONPath walks a dotted path such as "data.items.0.id".
+func lookupJSONPath(doc any, path string) (string, error) {
+	current := doc
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[part]
+			if !ok {
+				return "", fmt.Errorf("path %q: key %q not found", path, part)
+			}
+			current = next
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return "", fmt.Errorf("path %q: invalid index %q", path, part)
+			}
+			current = node[idx]
+		default:
+			return "", fmt.Errorf("path %q: cannot descend into %q", path, part)
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case nil:
+		return "", fmt.Errorf("path %q is null", path)
+	case map[string]any, []any:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
@@ -0,0 +1,184 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newFlowServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s-123", Path: "/"})
+		w.Header().Set("X-Request-Id", "req-7")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"token":"abc","items":[{"id":42}]}}`))
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s-123" {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("item=" + r.URL.Query().Get("id")))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCheckFlowPassesVariablesAndCookies(t *testing.T) {
+	server := newFlowServer()
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name: "login-flow",
+		Type: "flow",
+		Steps: []FlowStep{
+			{
+				Name:         "login",
+				Method:       "post",
+				URL:          server.URL + "/login",
+				Body:         `{"user":"demo"}`,
+				ExpectStatus: http.StatusOK,
+				Extract: map[string]string{
+					"token":   "json:data.token",
+					"item":    "json:data.items.0.id",
+					"request": "header:X-Request-Id",
+					"session": "cookie:session",
+				},
+			},
+			{
+				Name:       "api",
+				URL:        server.URL + "/api?id={{item}}",
+				Headers:    map[string]string{"Authorization": "Bearer {{ token }}"},
+				ExpectBody: "item=42",
+			},
+		},
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if result.Detail != "2/2 steps passed" {
+		t.Fatalf("detail=%q", result.Detail)
+	}
+	if len(result.Steps) != 2 || result.Steps[1].HTTPStatus != http.StatusOK {
+		t.Fatalf("unexpected steps: %#v", result.Steps)
+	}
+	if result.Target != server.URL+"/login" {
+		t.Fatalf("target=%q, want first step URL", result.Target)
+	}
+}
+
+func TestCheckFlowStopsAtFailingStep(t *testing.T) {
+	server := newFlowServer()
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name: "unauthorized-flow",
+		Type: "flow",
+		Steps: []FlowStep{
+			{Name: "api", URL: server.URL + "/api"},
+			{Name: "never", URL: server.URL + "/api"},
+		},
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if len(result.Steps) != 1 {
+		t.Fatalf("ran %d steps, want 1", len(result.Steps))
+	}
+	if !strings.Contains(result.Detail, `step "api": HTTP 401`) {
+		t.Fatalf("detail=%q", result.Detail)
+	}
+}
+
+func TestCheckFlowUndefinedVariable(t *testing.T) {
+	result := Check(context.Background(), Target{
+		Name:  "bad-vars",
+		Type:  "flow",
+		Steps: []FlowStep{{URL: "http://127.0.0.1/{{missing}}"}},
+	})
+
+	if result.Status != "error" {
+		t.Fatalf("status=%q, want error", result.Status)
+	}
+	if !strings.Contains(result.Detail, `step "step-1"`) || !strings.Contains(result.Detail, "missing") {
+		t.Fatalf("detail=%q", result.Detail)
+	}
+}
+
+func TestCheckFlowNoSteps(t *testing.T) {
+	result := Check(context.Background(), Target{Name: "empty", Type: "flow"})
+	if result.Status != "error" {
+		t.Fatalf("status=%q, want error", result.Status)
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	doc, err := decodeJSON([]byte(`{"a":{"b":[1,"two",{"c":true}],"n":null,"id":12345678901234567891,"big":1000000000000000000000}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"a.b.0", "1", false},
+		{"a.b.1", "two", false},
+		{"a.b.2.c", "true", false},
+		{"a.b.2", `{"c":true}`, false},
+		// Large integer IDs keep every digit and no exponent.
+		{"a.id", "12345678901234567891", false},
+		{"a.big", "1000000000000000000000", false},
+		{"a.b.9", "", true},
+		{"a.missing", "", true},
+		{"a.n", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := lookupJSONPath(doc, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("lookupJSONPath(%q) err=%v, wantErr=%v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("lookupJSONPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractFlowVarKeepsLargeIntegerID(t *testing.T) {
+	got, err := extractFlowVar("json:user.id", nil, []byte(`{"user":{"id":9007199254740993123}}`), nil)
+	if err != nil || got != "9007199254740993123" {
+		t.Fatalf("extractFlowVar() = %q, %v; want the ID verbatim", got, err)
+	}
+}
+
+func TestDecodeJSONRejectsTrailingData(t *testing.T) {
+	if _, err := decodeJSON([]byte(`{"a":1} {"b":2}`)); err == nil {
+		t.Fatal("decodeJSON accepted trailing data")
+	}
+}
+
+func TestStepResultMarshalJSONLatencyMilliseconds(t *testing.T) {
+	data, err := json.Marshal(Result{Name: "f", Steps: []StepResult{{Name: "s", Status: "up", Latency: 1500000000}}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"steps":[{"name":"s","status":"up","latency_ms":1500}]`) {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
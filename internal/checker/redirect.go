@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultMaxRedirects = 10
+
+// RedirectHop records one request/response pair in a redirect chain.
+type RedirectHop struct {
+	URL     string        `json:"url"`
+	Status  int           `json:"status"`
+	Latency time.Duration `json:"-"`
+}
+
+// MarshalJSON renders Latency as integer milliseconds under latency_ms.
+func (h RedirectHop) MarshalJSON() ([]byte, error) {
+	type hopJSON struct {
+		URL       string `json:"url"`
+		Status    int    `json:"status"`
+		LatencyMS int64  `json:"latency_ms"`
+	}
+
+	return json.Marshal(hopJSON{
+		URL:       h.URL,
+		Status:    h.Status,
+		LatencyMS: h.Latency.Milliseconds(),
+	})
+}
+
+// followRedirects issues req and follows Location headers itself so each
+// hop can be timed. client must not follow redirects on its own. The chain
+// always includes the final response.
+func followRedirects(client *http.Client, req *http.Request, maxHops int) (*http.Response, []RedirectHop, error) {
+	var hops []RedirectHop
+
+	for {
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, hops, err
+		}
+		hops = append(hops, RedirectHop{
+			URL:     req.URL.String(),
+			Status:  resp.StatusCode,
+			Latency: time.Since(start),
+		})
+
+		location := resp.Header.Get("Location")
+		if !isRedirect(resp.StatusCode) || location == "" {
+			return resp, hops, nil
+		}
+		resp.Body.Close()
+
+		if len(hops) > maxHops {
+			return nil, hops, fmt.Errorf("stopped after %d redirects", maxHops)
+		}
+
+		next, err := req.URL.Parse(location)
+		if err != nil {
+			return nil, hops, fmt.Errorf("invalid Location %q: %w", location, err)
+		}
+
+		nextReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, next.String(), nil)
+		if err != nil {
+			return nil, hops, err
+		}
+		for name, values := range req.Header {
+			if strings.EqualFold(name, "Authorization") && next.Host != req.URL.Host {
+				continue
+			}
+			nextReq.Header[name] = values
+		}
+		req = nextReq
+	}
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// sameURL compares URLs ignoring a trailing slash on the path.
+func sameURL(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
@@ -0,0 +1,123 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newRedirectChain(t *testing.T) (*httptest.Server, func()) {
+	t.Helper()
+
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/home" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("home"))
+	}))
+	entry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/www", http.StatusMovedPermanently)
+		case "/www":
+			http.Redirect(w, r, final.URL+"/home", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return entry, func() {
+		entry.Close()
+		final.Close()
+	}
+}
+
+func TestCheckHTTPFollowRedirectsRecordsChain(t *testing.T) {
+	entry, cleanup := newRedirectChain(t)
+	defer cleanup()
+
+	result := Check(context.Background(), Target{
+		Name:            "apex",
+		URL:             entry.URL,
+		Type:            "http",
+		FollowRedirects: true,
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if result.Detail != "HTTP 200 after 2 redirects" {
+		t.Fatalf("detail=%q", result.Detail)
+	}
+	if len(result.Redirects) != 3 {
+		t.Fatalf("len(redirects)=%d, want 3: %#v", len(result.Redirects), result.Redirects)
+	}
+	wantStatus := []int{http.StatusMovedPermanently, http.StatusFound, http.StatusOK}
+	for i, hop := range result.Redirects {
+		if hop.Status != wantStatus[i] {
+			t.Errorf("hop %d status=%d, want %d", i, hop.Status, wantStatus[i])
+		}
+	}
+	if !strings.HasSuffix(result.Redirects[2].URL, "/home") {
+		t.Fatalf("final hop URL=%q, want /home", result.Redirects[2].URL)
+	}
+}
+
+func TestCheckHTTPExpectFinalURL(t *testing.T) {
+	entry, cleanup := newRedirectChain(t)
+	defer cleanup()
+
+	result := Check(context.Background(), Target{
+		Name:            "apex-wrong",
+		URL:             entry.URL,
+		Type:            "http",
+		FollowRedirects: true,
+		ExpectFinalURL:  "https://www.example.com/",
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if !strings.Contains(result.Detail, "want https://www.example.com/") {
+		t.Fatalf("detail=%q", result.Detail)
+	}
+}
+
+func TestCheckHTTPMaxRedirects(t *testing.T) {
+	entry, cleanup := newRedirectChain(t)
+	defer cleanup()
+
+	result := Check(context.Background(), Target{
+		Name:            "loop",
+		URL:             entry.URL + "/loop",
+		Type:            "http",
+		FollowRedirects: true,
+		MaxRedirects:    3,
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if result.Detail != "stopped after 3 redirects" {
+		t.Fatalf("detail=%q", result.Detail)
+	}
+	if len(result.Redirects) != 4 {
+		t.Fatalf("len(redirects)=%d, want 4", len(result.Redirects))
+	}
+}
+
+func TestRedirectHopMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(RedirectHop{URL: "http://a", Status: 301, Latency: 2500000})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != `{"url":"http://a","status":301,"latency_ms":2}` {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
@@ -0,0 +1,262 @@
+package checker
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Burn-rate thresholds for multi-window alerting: a fast burn spends 2% of
+// a 30-day budget in one hour, a slow burn spends 5% in six hours.
+const (
+	FastBurnWindow    = time.Hour
+	FastBurnThreshold = 14.4
+	SlowBurnWindow    = 6 * time.Hour
+	SlowBurnThreshold = 6.0
+)
+
+const (
+	defaultSLOWindowDays        = 30
+	defaultSLOLatencyPercentile = 95
+)
+
+// SLO declares service level objectives for a target.
+type SLO struct {
+	// Availability is the target percentage of "up" checks, e.g. 99.9.
+	// It must be below 100 so that some error budget exists.
+	Availability float64 `json:"availability,omitempty"`
+	// LatencyMS is the latency objective for LatencyPercentile (default 95).
+	LatencyMS         int     `json:"latency_ms,omitempty"`
+	LatencyPercentile float64 `json:"latency_percentile,omitempty"`
+	// WindowDays is the rolling compliance window (default 30).
+	WindowDays int `json:"window_days,omitempty"`
+}
+
+// HistoryRecord is one check outcome persisted for SLO evaluation.
+type HistoryRecord struct {
+	Time      time.Time `json:"time"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	LatencyMS int64     `json:"latency_ms"`
+}
+
+// SLOReport summarises SLO compliance for one target over its window.
+type SLOReport struct {
+	Name       string `json:"name"`
+	WindowDays int    `json:"window_days"`
+	Samples    int    `json:"samples"`
+
+	Availability          float64 `json:"availability"`
+	AvailabilityObjective float64 `json:"availability_objective,omitempty"`
+	// ErrorBudgetRemaining is the percentage of the window's error budget
+	// left; it goes negative once the budget is overspent.
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+	BurnRate             float64 `json:"burn_rate"`
+	FastBurnRate         float64 `json:"fast_burn_rate"`
+	SlowBurnRate         float64 `json:"slow_burn_rate"`
+
+	LatencyPercentile  float64 `json:"latency_percentile,omitempty"`
+	LatencyMS          int64   `json:"latency_ms,omitempty"`
+	LatencyObjectiveMS int     `json:"latency_objective_ms,omitempty"`
+
+	Alerts []string `json:"alerts,omitempty"` // fast_burn, slow_burn, budget_exhausted, latency
+}
+
+// Validate reports objectives that cannot be evaluated.
+func (s SLO) Validate() error {
+	if s.Availability < 0 || s.Availability >= 100 {
+		return fmt.Errorf("availability %.4g must be in [0, 100)", s.Availability)
+	}
+	if s.LatencyMS < 0 {
+		return fmt.Errorf("latency_ms %d must not be negative", s.LatencyMS)
+	}
+	if s.LatencyPercentile < 0 || s.LatencyPercentile > 100 {
+		return fmt.Errorf("latency_percentile %.4g must be in [0, 100]", s.LatencyPercentile)
+	}
+	if s.WindowDays < 0 {
+		return fmt.Errorf("window_days %d must not be negative", s.WindowDays)
+	}
+	return nil
+}
+
+// NewHistoryRecord captures the fields of r needed for SLO evaluation.
+func NewHistoryRecord(r Result, at time.Time) HistoryRecord {
+	return HistoryRecord{
+		Time:      at.UTC(),
+		Name:      r.Name,
+		Status:    r.Status,
+		LatencyMS: r.Latency.Milliseconds(),
+	}
+}
+
+// AppendHistory appends results to a JSON-lines history file.
+func AppendHistory(path string, results []Result, at time.Time) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history file: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		if err := encoder.Encode(NewHistoryRecord(result, at)); err != nil {
+			f.Close()
+			return fmt.Errorf("write history: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write history: %w", err)
+	}
+
+	return f.Close()
+}
+
+// LoadHistory reads a JSON-lines history file. A missing file yields no
+// records rather than an error.
+func LoadHistory(path string) ([]HistoryRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open history file: %w", err)
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("parse history line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history file: %w", err)
+	}
+
+	return records, nil
+}
+
+// EvaluateSLO computes compliance, error budget and burn rates for the
+// named target from records, as of now.
+func EvaluateSLO(name string, slo SLO, records []HistoryRecord, now time.Time) SLOReport {
+	windowDays := slo.WindowDays
+	if windowDays <= 0 {
+		windowDays = defaultSLOWindowDays
+	}
+	window := time.Duration(windowDays) * 24 * time.Hour
+
+	report := SLOReport{
+		Name:                  name,
+		WindowDays:            windowDays,
+		AvailabilityObjective: slo.Availability,
+		LatencyObjectiveMS:    slo.LatencyMS,
+	}
+
+	var inWindow []HistoryRecord
+	for _, record := range records {
+		if record.Name == name && !record.Time.After(now) && now.Sub(record.Time) < window {
+			inWindow = append(inWindow, record)
+		}
+	}
+	report.Samples = len(inWindow)
+	if report.Samples == 0 {
+		return report
+	}
+
+	total, bad := countBad(inWindow, now, window)
+	report.Availability = 100 * float64(total-bad) / float64(total)
+
+	if slo.Availability > 0 {
+		budget := 1 - slo.Availability/100
+		report.BurnRate = burnRate(total, bad, budget)
+		report.ErrorBudgetRemaining = 100 * (1 - report.BurnRate)
+
+		fastTotal, fastBad := countBad(inWindow, now, FastBurnWindow)
+		report.FastBurnRate = burnRate(fastTotal, fastBad, budget)
+		slowTotal, slowBad := countBad(inWindow, now, SlowBurnWindow)
+		report.SlowBurnRate = burnRate(slowTotal, slowBad, budget)
+
+		if report.FastBurnRate >= FastBurnThreshold {
+			report.Alerts = append(report.Alerts, "fast_burn")
+		}
+		if report.SlowBurnRate >= SlowBurnThreshold {
+			report.Alerts = append(report.Alerts, "slow_burn")
+		}
+		if report.ErrorBudgetRemaining <= 0 {
+			report.Alerts = append(report.Alerts, "budget_exhausted")
+		}
+	}
+
+	if slo.LatencyMS > 0 {
+		report.LatencyPercentile = slo.LatencyPercentile
+		if report.LatencyPercentile <= 0 || report.LatencyPercentile > 100 {
+			report.LatencyPercentile = defaultSLOLatencyPercentile
+		}
+
+		var latencies []int64
+		for _, record := range inWindow {
+			if record.Status == "up" {
+				latencies = append(latencies, record.LatencyMS)
+			}
+		}
+		report.LatencyMS = percentile(latencies, report.LatencyPercentile)
+		if report.LatencyMS > int64(slo.LatencyMS) {
+			report.Alerts = append(report.Alerts, "latency")
+		}
+	}
+
+	return report
+}
+
+// countBad returns the number of records and non-"up" records within
+// window of now.
+func countBad(records []HistoryRecord, now time.Time, window time.Duration) (int, int) {
+	total, bad := 0, 0
+	for _, record := range records {
+		if now.Sub(record.Time) >= window {
+			continue
+		}
+		total++
+		if record.Status != "up" {
+			bad++
+		}
+	}
+	return total, bad
+}
+
+// burnRate is the observed error rate divided by the allowed error rate.
+func burnRate(total, bad int, budget float64) float64 {
+	if total == 0 || budget <= 0 {
+		return 0
+	}
+	return float64(bad) / float64(total) / budget
+}
+
+// percentile returns the nearest-rank percentile of values, or 0 if empty.
+func percentile(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
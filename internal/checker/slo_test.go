@@ -0,0 +1,157 @@
+package checker
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func historyAt(now time.Time, name string, ago time.Duration, status string, latencyMS int64) HistoryRecord {
+	return HistoryRecord{Time: now.Add(-ago), Name: name, Status: status, LatencyMS: latencyMS}
+}
+
+func TestEvaluateSLOBudgetAndBurn(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var records []HistoryRecord
+	// 1000 checks spread over the last 10 days, 2 failures days ago.
+	for i := 0; i < 1000; i++ {
+		status := "up"
+		if i == 200 || i == 300 {
+			status = "down"
+		}
+		records = append(records, historyAt(now, "api", time.Duration(i)*14*time.Minute+time.Minute, status, int64(100+i%100)))
+	}
+	records = append(records, historyAt(now, "other", time.Minute, "down", 0))
+	records = append(records, historyAt(now, "api", 40*24*time.Hour, "down", 0))
+
+	report := EvaluateSLO("api", SLO{Availability: 99.9, LatencyMS: 300}, records, now)
+
+	if report.Samples != 1000 {
+		t.Fatalf("samples=%d, want 1000", report.Samples)
+	}
+	if math.Abs(report.Availability-99.8) > 1e-9 {
+		t.Fatalf("availability=%v, want 99.8", report.Availability)
+	}
+	if math.Abs(report.BurnRate-2) > 1e-9 {
+		t.Fatalf("burn rate=%v, want 2", report.BurnRate)
+	}
+	if math.Abs(report.ErrorBudgetRemaining+100) > 1e-6 {
+		t.Fatalf("budget remaining=%v, want -100", report.ErrorBudgetRemaining)
+	}
+	if report.FastBurnRate != 0 || report.SlowBurnRate != 0 {
+		t.Fatalf("fast=%v slow=%v, want 0 (failures are days old)", report.FastBurnRate, report.SlowBurnRate)
+	}
+	if report.LatencyPercentile != 95 || report.LatencyMS != 195 {
+		t.Fatalf("p%v latency=%d, want p95 195", report.LatencyPercentile, report.LatencyMS)
+	}
+	if !reflect.DeepEqual(report.Alerts, []string{"budget_exhausted"}) {
+		t.Fatalf("alerts=%v, want [budget_exhausted]", report.Alerts)
+	}
+}
+
+func TestEvaluateSLOFastBurn(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var records []HistoryRecord
+	for i := 0; i < 10000; i++ {
+		records = append(records, historyAt(now, "api", 2*time.Hour+time.Duration(i)*time.Minute, "up", 50))
+	}
+	for i := 0; i < 10; i++ {
+		status := "up"
+		if i < 2 {
+			status = "down"
+		}
+		records = append(records, historyAt(now, "api", time.Duration(i)*5*time.Minute, status, 900))
+	}
+
+	report := EvaluateSLO("api", SLO{Availability: 99, LatencyMS: 300, LatencyPercentile: 99.99}, records, now)
+
+	if math.Abs(report.FastBurnRate-20) > 1e-9 {
+		t.Fatalf("fast burn=%v, want 20", report.FastBurnRate)
+	}
+	if math.Abs(report.SlowBurnRate-0.8) > 1e-9 {
+		t.Fatalf("slow burn=%v, want 0.8", report.SlowBurnRate)
+	}
+	want := []string{"fast_burn", "latency"}
+	if !reflect.DeepEqual(report.Alerts, want) {
+		t.Fatalf("alerts=%v, want %v", report.Alerts, want)
+	}
+	if report.ErrorBudgetRemaining <= 0 {
+		t.Fatalf("budget remaining=%v, want positive", report.ErrorBudgetRemaining)
+	}
+}
+
+func TestEvaluateSLONoSamples(t *testing.T) {
+	report := EvaluateSLO("api", SLO{Availability: 99.9}, nil, time.Now())
+	if report.Samples != 0 || len(report.Alerts) != 0 || report.WindowDays != 30 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestSLOValidate(t *testing.T) {
+	valid := SLO{Availability: 99.9, LatencyMS: 300, LatencyPercentile: 95, WindowDays: 7}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	for _, slo := range []SLO{
+		{Availability: 100},
+		{LatencyMS: -1},
+		{LatencyPercentile: 101},
+		{WindowDays: -1},
+	} {
+		if err := slo.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", slo)
+		}
+	}
+}
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	results := []Result{
+		{Name: "a", Status: "up", Latency: 120 * time.Millisecond},
+		{Name: "b", Status: "down", Latency: 5 * time.Second},
+	}
+	if err := AppendHistory(path, results, at); err != nil {
+		t.Fatalf("AppendHistory: %v", err)
+	}
+	if err := AppendHistory(path, results[:1], at.Add(time.Minute)); err != nil {
+		t.Fatalf("AppendHistory: %v", err)
+	}
+
+	records, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory: %v", err)
+	}
+	want := []HistoryRecord{
+		{Time: at, Name: "a", Status: "up", LatencyMS: 120},
+		{Time: at, Name: "b", Status: "down", LatencyMS: 5000},
+		{Time: at.Add(time.Minute), Name: "a", Status: "up", LatencyMS: 120},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("records=%+v, want %+v", records, want)
+	}
+}
+
+func TestLoadHistoryMissingAndInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	records, err := LoadHistory(filepath.Join(dir, "missing.jsonl"))
+	if err != nil || records != nil {
+		t.Fatalf("missing file: records=%v err=%v, want nil nil", records, err)
+	}
+
+	path := filepath.Join(dir, "bad.jsonl")
+	if err := os.WriteFile(path, []byte("{not json}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHistory(path); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
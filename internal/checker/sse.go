@@ -0,0 +1,151 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sseEvent is a dispatched Server-Sent Event.
+type sseEvent struct {
+	Name string
+	Data string
+}
+
+// checkSSE connects to an event stream and waits for the expected number
+// of events, or for an event matching target.Expect when one is set.
+func checkSSE(ctx context.Context, target Target) Result {
+	start := time.Now()
+	result := Result{
+		Name:   target.Name,
+		Type:   "sse",
+		Target: target.URL,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		result.Status = "error"
+		result.Detail = fmt.Sprintf("build request: %v", err)
+		result.Latency = time.Since(start)
+		return result
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	client, err := httpClientFor(target)
+	if err != nil {
+		result.Status = "error"
+		result.Detail = err.Error()
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Status = "down"
+		result.Detail = err.Error()
+		result.Latency = time.Since(start)
+		return result
+	}
+	defer resp.Body.Close()
+	result.TLS = tlsInfo(resp.TLS)
+
+	if resp.StatusCode != http.StatusOK {
+		result.Status = "down"
+		result.Detail = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		result.Latency = time.Since(start)
+		return result
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		result.Status = "down"
+		result.Detail = fmt.Sprintf("unexpected content type %q", resp.Header.Get("Content-Type"))
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	want := target.ExpectEvents
+	if want <= 0 {
+		want = 1
+	}
+
+	received := 0
+	err = readSSE(bufio.NewScanner(resp.Body), func(event sseEvent) bool {
+		received++
+		if target.Expect != "" {
+			return strings.Contains(event.Name, target.Expect) || strings.Contains(event.Data, target.Expect)
+		}
+		return received >= want
+	})
+	result.Latency = time.Since(start)
+
+	switch {
+	case err == nil && target.Expect != "":
+		result.Status = "up"
+		result.Detail = fmt.Sprintf("matched %q after %d events", target.Expect, received)
+	case err == nil:
+		result.Status = "up"
+		result.Detail = fmt.Sprintf("received %d events", received)
+	default:
+		reason := err.Error()
+		if ctx.Err() != nil {
+			reason = "timeout"
+		}
+		result.Status = "down"
+		if target.Expect != "" {
+			result.Detail = fmt.Sprintf("no event matching %q in %d events (%s)", target.Expect, received, reason)
+		} else {
+			result.Detail = fmt.Sprintf("received %d of %d events (%s)", received, want, reason)
+		}
+	}
+
+	return result
+}
+
+// readSSE parses an event stream and calls done for each dispatched event
+// until it returns true. It returns an error if the stream ends first.
+func readSSE(scanner *bufio.Scanner, done func(sseEvent) bool) error {
+	var (
+		event   sseEvent
+		data    []string
+		hasData bool
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if hasData {
+				event.Data = strings.Join(data, "\n")
+				if done(event) {
+					return nil
+				}
+			}
+			event, data, hasData = sseEvent{}, data[:0], false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Name = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("stream closed")
+}
@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSSEServer(events []string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+		_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+		for _, event := range events {
+			_, _ = fmt.Fprint(w, event)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+}
+
+func TestCheckSSEReceivesEvents(t *testing.T) {
+	server := newSSEServer([]string{
+		"data: one\n\n",
+		"event: tick\ndata: two\ndata: lines\n\n",
+	})
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:         "sse",
+		URL:          server.URL,
+		Type:         "sse",
+		ExpectEvents: 2,
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if result.Detail != "received 2 events" {
+		t.Fatalf("detail=%q, want received 2 events", result.Detail)
+	}
+}
+
+func TestCheckSSEMatchesEvent(t *testing.T) {
+	server := newSSEServer([]string{
+		"data: warming up\n\n",
+		"event: ready\ndata: {}\n\n",
+	})
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:   "sse-match",
+		URL:    server.URL,
+		Type:   "sse",
+		Expect: "ready",
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if !strings.Contains(result.Detail, "after 2 events") {
+		t.Fatalf("detail=%q, want match after 2 events", result.Detail)
+	}
+}
+
+func TestCheckSSETooFewEventsTimesOut(t *testing.T) {
+	server := newSSEServer([]string{"data: only\n\n"})
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:         "sse-short",
+		URL:          server.URL,
+		Type:         "sse",
+		ExpectEvents: 3,
+		Timeout:      200,
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if !strings.Contains(result.Detail, "received 1 of 3 events (timeout)") {
+		t.Fatalf("detail=%q, want timeout detail", result.Detail)
+	}
+}
+
+func TestCheckSSEWrongContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name: "sse-json",
+		URL:  server.URL,
+		Type: "sse",
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if !strings.Contains(result.Detail, "unexpected content type") {
+		t.Fatalf("detail=%q, want content type detail", result.Detail)
+	}
+}
@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TLSOptions customises the TLS client used to reach a target.
+type TLSOptions struct {
+	CertFile   string `json:"cert_file,omitempty"`
+	KeyFile    string `json:"key_file,omitempty"`
+	CAFile     string `json:"ca_file,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+	// MinVersion is one of "1.0", "1.1", "1.2" or "1.3".
+	MinVersion string `json:"min_version,omitempty"`
+	// CipherSuites restricts TLS 1.0-1.2 suites by their Go names, e.g.
+	// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". TLS 1.3 suites are fixed.
+	CipherSuites       []string `json:"cipher_suites,omitempty"`
+	InsecureSkipVerify bool     `json:"insecure_skip_verify,omitempty"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// clientConfig builds a tls.Config from the options, loading any
+// certificate and CA files they reference.
+func (o *TLSOptions) clientConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify, //nolint:gosec // explicit per-target opt-in
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, fmt.Errorf("cert_file and key_file must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.MinVersion != "" {
+		version, ok := tlsVersions[strings.TrimSpace(o.MinVersion)]
+		if !ok {
+			return nil, fmt.Errorf("unknown min_version %q", o.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+
+	if len(o.CipherSuites) > 0 {
+		ids, err := cipherSuiteIDs(o.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CipherSuites = ids
+	}
+
+	return cfg, nil
+}
+
+func cipherSuiteIDs(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+var (
+	clientCacheMu sync.Mutex
+	clientCache   = make(map[string]*http.Client)
+)
+
+// transportKey identifies the transport options of a target. Targets with
+// equal keys share one cached HTTP client.
+type transportKey struct {
+	TLS       *TLSOptions       `json:"tls,omitempty"`
+	Proxy     string            `json:"proxy,omitempty"`
+	IPFamily  IPFamily          `json:"ip_family,omitempty"`
+	LocalAddr string            `json:"local_addr,omitempty"`
+	Resolve   map[string]string `json:"resolve,omitempty"`
+}
+
+// httpClientFor returns the HTTP client for a target. Targets without
+// transport options share sharedHTTPClient; otherwise one client is
+// cached per distinct option set so connections are pooled across workers.
+func httpClientFor(target Target) (*http.Client, error) {
+	opts := transportKey{
+		TLS:       target.TLSConfig,
+		Proxy:     target.Proxy,
+		IPFamily:  target.IPFamily,
+		LocalAddr: target.LocalAddr,
+		Resolve:   target.Resolve,
+	}
+	if opts.TLS == nil && opts.Proxy == "" && opts.IPFamily == IPFamilyAny && opts.LocalAddr == "" && len(opts.Resolve) == 0 {
+		return sharedHTTPClient, nil
+	}
+
+	key, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCacheMu.Lock()
+	defer clientCacheMu.Unlock()
+
+	if client, ok := clientCache[string(key)]; ok {
+		return client, nil
+	}
+
+	transport := sharedHTTPClient.Transport.(*http.Transport).Clone()
+
+	if target.TLSConfig != nil {
+		tlsConfig, err := target.TLSConfig.clientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("tls config: %w", err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	dialer, err := newTargetDialer(target)
+	if err != nil {
+		return nil, err
+	}
+	transport.DialContext = dialer.DialContext
+	if dialer.proxy != nil {
+		transport.Proxy = http.ProxyURL(dialer.proxy)
+	}
+
+	client := &http.Client{
+		Transport:     transport,
+		CheckRedirect: sharedHTTPClient.CheckRedirect,
+	}
+	clientCache[string(key)] = client
+	return client, nil
+}
+
+// probeTLSConfig returns the config used by the tcp check's certificate
+// probe. The probe only reports the peer certificate, so verification is
+// skipped, but client certificates, SNI and version limits still apply.
+func probeTLSConfig(target Target) *tls.Config {
+	cfg := &tls.Config{}
+	if target.TLSConfig != nil {
+		if built, err := target.TLSConfig.clientConfig(); err == nil {
+			cfg = built
+		}
+	}
+	cfg.InsecureSkipVerify = true //nolint:gosec // probe tool intentionally accepts unknown certs
+	return cfg
+}
@@ -0,0 +1,249 @@
+package checker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPKI struct {
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverCert tls.Certificate
+	caPool     *x509.CertPool
+}
+
+// newTestPKI creates a CA plus a server certificate for 127.0.0.1 and a
+// client certificate, writing the CA and client pair to PEM files.
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage, ips []net.IP, dns []string) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(24 * time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  ips,
+			DNSNames:     dns,
+		}, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, key
+	}
+
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	serverDER, serverKey := issue(2, "server", x509.ExtKeyUsageServerAuth, []net.IP{net.ParseIP("127.0.0.1")}, []string{"internal.test"})
+	clientDER, clientKey := issue(3, "client", x509.ExtKeyUsageClientAuth, nil, nil)
+
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return testPKI{
+		caFile:     writePEM("ca.pem", "CERTIFICATE", caDER),
+		certFile:   writePEM("client.pem", "CERTIFICATE", clientDER),
+		keyFile:    writePEM("client-key.pem", "EC PRIVATE KEY", clientKeyDER),
+		serverCert: tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey},
+		caPool:     pool,
+	}
+}
+
+func newMTLSServer(t *testing.T, pki testPKI) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.ServerName))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pki.caPool,
+	}
+	server.StartTLS()
+	return server
+}
+
+func TestCheckHTTPWithClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	server := newMTLSServer(t, pki)
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name: "mtls",
+		URL:  server.URL,
+		Type: "http",
+		TLSConfig: &TLSOptions{
+			CertFile:   pki.certFile,
+			KeyFile:    pki.keyFile,
+			CAFile:     pki.caFile,
+			MinVersion: "1.2",
+		},
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if result.TLS == nil || result.TLS.Subject != "server" {
+		t.Fatalf("unexpected TLS info: %#v", result.TLS)
+	}
+}
+
+func TestCheckHTTPWithoutClientCertificateFails(t *testing.T) {
+	pki := newTestPKI(t)
+	server := newMTLSServer(t, pki)
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:      "no-client-cert",
+		URL:       server.URL,
+		Type:      "http",
+		TLSConfig: &TLSOptions{CAFile: pki.caFile},
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+}
+
+func TestCheckHTTPServerNameOverride(t *testing.T) {
+	pki := newTestPKI(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS.ServerName != "internal.test" {
+			http.Error(w, "wrong SNI", http.StatusMisdirectedRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{pki.serverCert}}
+	server.StartTLS()
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:      "sni",
+		URL:       server.URL,
+		Type:      "http",
+		TLSConfig: &TLSOptions{CAFile: pki.caFile, ServerName: "internal.test"},
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+}
+
+func TestHTTPClientForCachesByOptions(t *testing.T) {
+	opts := &TLSOptions{InsecureSkipVerify: true, MinVersion: "1.3"}
+
+	first, err := httpClientFor(Target{TLSConfig: opts})
+	if err != nil {
+		t.Fatalf("httpClientFor: %v", err)
+	}
+	second, err := httpClientFor(Target{TLSConfig: &TLSOptions{InsecureSkipVerify: true, MinVersion: "1.3"}})
+	if err != nil {
+		t.Fatalf("httpClientFor: %v", err)
+	}
+	if first != second {
+		t.Fatal("expected identical options to share a cached client")
+	}
+
+	plain, err := httpClientFor(Target{})
+	if err != nil {
+		t.Fatalf("httpClientFor: %v", err)
+	}
+	if plain != sharedHTTPClient {
+		t.Fatal("expected targets without options to use the shared client")
+	}
+}
+
+func TestTLSOptionsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TLSOptions
+		want string
+	}{
+		{"cert without key", TLSOptions{CertFile: "client.pem"}, "must be set together"},
+		{"missing CA", TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, "read CA file"},
+		{"bad version", TLSOptions{MinVersion: "2.0"}, "unknown min_version"},
+		{"bad cipher", TLSOptions{CipherSuites: []string{"TLS_NOPE"}}, "unknown cipher suite"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Check(context.Background(), Target{
+				Name:      tt.name,
+				URL:       "https://127.0.0.1:1",
+				Type:      "http",
+				TLSConfig: &tt.opts,
+			})
+			if result.Status != "error" || !strings.Contains(result.Detail, tt.want) {
+				t.Fatalf("status=%q detail=%q, want error containing %q", result.Status, result.Detail, tt.want)
+			}
+		})
+	}
+}
+
+func TestCipherSuiteIDs(t *testing.T) {
+	ids, err := cipherSuiteIDs([]string{"tls_ecdhe_rsa_with_aes_128_gcm_sha256"})
+	if err != nil {
+		t.Fatalf("cipherSuiteIDs: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("ids=%v", ids)
+	}
+}
@@ -0,0 +1,338 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // required by RFC 6455 handshake
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketPayload bounds a single message so a misbehaving peer
+// cannot make the probe allocate unbounded memory.
+const maxWebSocketPayload = 1 << 20
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+var errWebSocketClosed = errors.New("connection closed by peer")
+
+// checkWebSocket upgrades the connection, optionally sends a message,
+// waits for a reply when one is expected and performs a clean close.
+func checkWebSocket(ctx context.Context, target Target) Result {
+	start := time.Now()
+	result := Result{
+		Name:   target.Name,
+		Type:   "websocket",
+		Target: target.URL,
+	}
+
+	fail := func(status, format string, args ...any) Result {
+		result.Status = status
+		result.Detail = fmt.Sprintf(format, args...)
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	httpURL, err := websocketHTTPURL(target.URL)
+	if err != nil {
+		return fail("error", "build request: %v", err)
+	}
+
+	key, err := websocketKey()
+	if err != nil {
+		return fail("error", "generate key: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpURL, nil)
+	if err != nil {
+		return fail("error", "build request: %v", err)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	client, err := httpClientFor(target)
+	if err != nil {
+		return fail("error", "%v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail("down", "%v", err)
+	}
+	result.TLS = tlsInfo(resp.TLS)
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return fail("down", "HTTP %d (expected 101 Switching Protocols)", resp.StatusCode)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		resp.Body.Close()
+		return fail("down", "invalid Sec-WebSocket-Accept header")
+	}
+
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return fail("error", "upgraded response body is not writable")
+	}
+	defer rwc.Close()
+
+	// The upgraded stream has no deadlines of its own, so closing it is
+	// the only way to unblock reads once the check context expires.
+	stop := context.AfterFunc(ctx, func() { _ = rwc.Close() })
+	defer stop()
+
+	conn := &wsConn{r: bufio.NewReader(rwc), w: rwc}
+
+	if target.Send != "" {
+		if err := conn.writeFrame(wsOpText, []byte(target.Send)); err != nil {
+			return fail("down", "send: %v", err)
+		}
+	}
+
+	var received string
+	if target.Send != "" || target.Expect != "" {
+		msg, err := conn.readMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return fail("down", "no message received before timeout")
+			}
+			return fail("down", "read: %v", err)
+		}
+		received = string(msg)
+		if target.Expect != "" && !strings.Contains(received, target.Expect) {
+			return fail("down", "message %q does not contain %q", truncate(received, 80), target.Expect)
+		}
+	}
+
+	if err := conn.close(); err != nil {
+		if ctx.Err() != nil {
+			return fail("down", "close handshake timed out")
+		}
+		return fail("down", "close handshake: %v", err)
+	}
+
+	result.Status = "up"
+	result.Latency = time.Since(start)
+	if received != "" {
+		result.Detail = fmt.Sprintf("upgraded, received %d bytes, closed cleanly", len(received))
+	} else {
+		result.Detail = "upgraded, closed cleanly"
+	}
+	return result
+}
+
+// websocketHTTPURL maps ws/wss URLs onto the http/https schemes the
+// HTTP client understands.
+func websocketHTTPURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	return u.String(), nil
+}
+
+func websocketKey() (string, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(nonce[:]), nil
+}
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID)) //nolint:gosec // required by RFC 6455
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// wsConn implements the client side of RFC 6455 framing needed by the probe.
+type wsConn struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+// writeFrame sends a single masked frame, as required for clients.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 0, 14)
+	header = append(header, 0x80|opcode)
+
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, 0x80|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 0x80|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	header = append(header, mask[:]...)
+
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+
+	_, err := c.w.Write(append(header, masked...))
+	return err
+}
+
+// readFrame reads one frame and returns its FIN bit, opcode and payload.
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := head[0]&0x80 != 0
+	opcode := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if length > maxWebSocketPayload {
+		return false, 0, nil, fmt.Errorf("frame of %d bytes exceeds limit", length)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// readMessage returns the next complete text or binary message, answering
+// pings along the way.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	inMessage := false
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			return nil, errWebSocketClosed
+		case wsOpText, wsOpBinary:
+			if inMessage {
+				return nil, errors.New("new message started before previous one finished")
+			}
+			inMessage = true
+			message = payload
+		case wsOpContinuation:
+			if !inMessage {
+				return nil, errors.New("unexpected continuation frame")
+			}
+			if len(message)+len(payload) > maxWebSocketPayload {
+				return nil, errors.New("message exceeds size limit")
+			}
+			message = append(message, payload...)
+		default:
+			return nil, fmt.Errorf("unknown opcode 0x%x", opcode)
+		}
+
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// close sends a normal-closure frame and waits for the peer's close frame.
+func (c *wsConn) close() error {
+	payload := binary.BigEndian.AppendUint16(nil, 1000)
+	if err := c.writeFrame(wsOpClose, payload); err != nil {
+		return err
+	}
+
+	for {
+		_, opcode, _, err := c.readFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return errors.New("connection closed without close frame")
+			}
+			return err
+		}
+		if opcode == wsOpClose {
+			return nil
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,190 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newWebSocketServer starts a minimal RFC 6455 server. onMessage returns the
+// reply for each client message; an empty reply sends nothing.
+func newWebSocketServer(t *testing.T, greeting string, onMessage func(string) string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Errorf("response writer does not support hijacking")
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		accept := websocketAccept(r.Header.Get("Sec-WebSocket-Key"))
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+		_ = rw.Flush()
+
+		server := &wsConn{r: bufio.NewReader(rw), w: conn}
+		if greeting != "" {
+			_ = server.writeServerFrame(wsOpText, []byte(greeting))
+		}
+
+		for {
+			_, opcode, payload, err := server.readFrame()
+			if err != nil {
+				return
+			}
+			switch opcode {
+			case wsOpClose:
+				_ = server.writeServerFrame(wsOpClose, payload)
+				return
+			case wsOpText:
+				if reply := onMessage(string(payload)); reply != "" {
+					_ = server.writeServerFrame(wsOpPing, nil)
+					_ = server.writeServerFrame(wsOpText, []byte(reply))
+				}
+			}
+		}
+	}))
+}
+
+// writeServerFrame writes an unmasked frame as a server would.
+func (c *wsConn) writeServerFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode, byte(len(payload))}
+	_, err := c.w.Write(append(frame, payload...))
+	return err
+}
+
+func TestCheckWebSocketEcho(t *testing.T) {
+	server := newWebSocketServer(t, "", func(msg string) string { return "echo:" + msg })
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:   "ws-echo",
+		URL:    "ws" + strings.TrimPrefix(server.URL, "http"),
+		Type:   "websocket",
+		Send:   "ping",
+		Expect: "echo:ping",
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+	if !strings.Contains(result.Detail, "closed cleanly") {
+		t.Fatalf("detail=%q, want clean close", result.Detail)
+	}
+}
+
+func TestCheckWebSocketGreetingWithoutSend(t *testing.T) {
+	server := newWebSocketServer(t, "welcome", func(string) string { return "" })
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:   "ws-greeting",
+		URL:    server.URL,
+		Type:   "ws",
+		Expect: "welcome",
+	})
+
+	if result.Status != "up" {
+		t.Fatalf("status=%q, want up (detail=%s)", result.Status, result.Detail)
+	}
+}
+
+func TestCheckWebSocketUnexpectedMessage(t *testing.T) {
+	server := newWebSocketServer(t, "", func(string) string { return "nope" })
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:   "ws-mismatch",
+		URL:    server.URL,
+		Type:   "websocket",
+		Send:   "ping",
+		Expect: "pong",
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if !strings.Contains(result.Detail, "does not contain") {
+		t.Fatalf("detail=%q, want mismatch detail", result.Detail)
+	}
+}
+
+func TestCheckWebSocketNoReplyTimesOut(t *testing.T) {
+	server := newWebSocketServer(t, "", func(string) string { return "" })
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name:    "ws-silent",
+		URL:     server.URL,
+		Type:    "websocket",
+		Send:    "ping",
+		Timeout: 200,
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if !strings.Contains(result.Detail, "timeout") {
+		t.Fatalf("detail=%q, want timeout", result.Detail)
+	}
+}
+
+func TestCheckWebSocketUpgradeRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result := Check(context.Background(), Target{
+		Name: "ws-plain",
+		URL:  server.URL,
+		Type: "websocket",
+	})
+
+	if result.Status != "down" {
+		t.Fatalf("status=%q, want down", result.Status)
+	}
+	if !strings.Contains(result.Detail, "HTTP 200") {
+		t.Fatalf("detail=%q, want HTTP 200", result.Detail)
+	}
+}
+
+func TestWebSocketHTTPURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"ws://example.com/socket", "http://example.com/socket", false},
+		{"wss://example.com/socket", "https://example.com/socket", false},
+		{"https://example.com", "https://example.com", false},
+		{"ftp://example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := websocketHTTPURL(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("websocketHTTPURL(%q) err=%v, wantErr=%v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("websocketHTTPURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // AppConfig contains application and server configuration.
@@ -18,6 +19,14 @@ type AppConfig struct {
 	LogLevel     string `json:"log_level"`
 
 	JSONOutput bool `json:"json_output"`
+
+	// SnapshotPath is where counters are saved every
+	// SnapshotIntervalSeconds and on shutdown, and restored from on
+	// startup; empty disables persistence. PersistCounters names the
+	// counters kept; empty keeps all.
+	SnapshotPath            string   `json:"snapshot_path"`
+	SnapshotIntervalSeconds int      `json:"snapshot_interval_seconds"`
+	PersistCounters         []string `json:"persist_counters"`
 }
 
 func DefaultConfig() AppConfig {
@@ -28,6 +37,8 @@ func DefaultConfig() AppConfig {
 		DefaultGreet: "world",
 		LogLevel:     "info",
 		JSONOutput:   false,
+
+		SnapshotIntervalSeconds: 30,
 	}
 }
 
@@ -66,6 +77,11 @@ func (c AppConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// SnapshotInterval is how often counters are snapshotted.
+func (c AppConfig) SnapshotInterval() time.Duration {
+	return time.Duration(c.SnapshotIntervalSeconds) * time.Second
+}
+
 func applyEnvOverrides(cfg *AppConfig) {
 	if v := os.Getenv("APP_HOST"); v != "" {
 		cfg.Host = v
@@ -87,4 +103,20 @@ func applyEnvOverrides(cfg *AppConfig) {
 	if v := os.Getenv("APP_JSON_OUTPUT"); v != "" {
 		cfg.JSONOutput = v == "true" || v == "1"
 	}
+	if v := os.Getenv("APP_SNAPSHOT_PATH"); v != "" {
+		cfg.SnapshotPath = v
+	}
+	if v := os.Getenv("APP_SNAPSHOT_INTERVAL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			cfg.SnapshotIntervalSeconds = seconds
+		}
+	}
+	if v := os.Getenv("APP_PERSIST_COUNTERS"); v != "" {
+		cfg.PersistCounters = nil
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.PersistCounters = append(cfg.PersistCounters, name)
+			}
+		}
+	}
 }
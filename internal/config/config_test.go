@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -79,6 +80,35 @@ func TestLoadEnvOverridesFile(t *testing.T) {
 	}
 }
 
+func TestLoadSnapshotSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := []byte(`{"snapshot_path": "/var/lib/app/counters.json", "persist_counters": ["hello_requests"]}`)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SnapshotPath != "/var/lib/app/counters.json" || len(cfg.PersistCounters) != 1 {
+		t.Errorf("snapshot settings = %q, %v", cfg.SnapshotPath, cfg.PersistCounters)
+	}
+	if cfg.SnapshotInterval() != 30*time.Second {
+		t.Errorf("SnapshotInterval() = %s, want default 30s", cfg.SnapshotInterval())
+	}
+
+	t.Setenv("APP_PERSIST_COUNTERS", "hello_requests, http_requests_total,")
+	t.Setenv("APP_SNAPSHOT_INTERVAL_SECONDS", "5")
+	cfg, _ = Load(path)
+	if len(cfg.PersistCounters) != 2 || cfg.PersistCounters[1] != "http_requests_total" {
+		t.Errorf("PersistCounters from env = %q", cfg.PersistCounters)
+	}
+	if cfg.SnapshotInterval() != 5*time.Second {
+		t.Errorf("SnapshotInterval() from env = %s, want 5s", cfg.SnapshotInterval())
+	}
+}
+
 func TestLoadInvalidJSON(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "bad.json")
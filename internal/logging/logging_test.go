@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"info":    slog.LevelInfo,
+		"DEBUG":   slog.LevelDebug,
+		" warn ":  slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"Error":   slog.LevelError,
+	}
+	for name, want := range tests {
+		got, err := ParseLevel(name)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) = nil error")
+	}
+}
+
+func TestNewJSONHonoursLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("dropped")
+	logger.Warn("kept", "port", 8080)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want only the warning: %q", len(lines), buf.String())
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("not JSON: %v", err)
+	}
+	if entry["msg"] != "kept" || entry["level"] != "WARN" || entry["port"] != float64(8080) {
+		t.Fatalf("entry = %v", entry)
+	}
+}
+
+func TestNewText(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "debug", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("hello", "name", "Nick")
+	if got := buf.String(); !strings.Contains(got, "level=DEBUG msg=hello name=Nick") {
+		t.Fatalf("text output = %q", got)
+	}
+	if _, err := New(&buf, "loud", false); err == nil {
+		t.Fatal("New() accepted an unknown level")
+	}
+}
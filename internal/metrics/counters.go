@@ -2,20 +2,37 @@ package metrics
 
 import (
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
 
-// Counters is a thread-safe in-memory counter store.
+// Counters is a thread-safe in-memory metric store. Plain counters are
+// addressed by (normalised) name; labeled counters, gauges, histograms and
+// summaries are created by name with CounterVec, Gauge, Histogram and
+// Summary.
 type Counters struct {
-	mu     sync.RWMutex
-	values map[string]uint64
+	mu         sync.RWMutex
+	values     map[string]uint64
+	vecs       map[string]*CounterVec
+	gauges     map[string]*Gauge
+	histograms map[string]*Histogram
+	summaries  map[string]*Summary
+	help       map[string]string
+	meters     map[string]*Meter
 }
 
 func NewCounters() *Counters {
 	return &Counters{
-		values: make(map[string]uint64),
+		values:     make(map[string]uint64),
+		vecs:       make(map[string]*CounterVec),
+		gauges:     make(map[string]*Gauge),
+		histograms: make(map[string]*Histogram),
+		summaries:  make(map[string]*Summary),
+		help:       make(map[string]string),
+		meters:     make(map[string]*Meter),
 	}
 }
 
@@ -23,16 +40,55 @@ func (c *Counters) Inc(name string) uint64 {
 	return c.Add(name, 1)
 }
 
+// Add increases the plain counter called name by delta and returns its new
+// value. It panics if name belongs to a counter vector, gauge, histogram or
+// summary, since both would render as one family.
 func (c *Counters) Add(name string, delta uint64) uint64 {
 	normalized := normalizeName(name)
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.typed(normalized) {
+		panic(fmt.Sprintf("metrics: counter %q already registered as another kind", normalized))
+	}
 	c.values[normalized] += delta
+	if meter := c.meters[normalized]; meter != nil {
+		meter.Mark(float64(delta))
+	}
 	return c.values[normalized]
 }
 
+// Meter returns the meter tracking the plain counter called name over
+// StandardSpans, creating it on first use. Only increments made after that
+// are counted.
+func (c *Counters) Meter(name string) *Meter {
+	normalized := normalizeName(name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	meter := c.meters[normalized]
+	if meter == nil {
+		meter = NewMeter()
+		c.meters[normalized] = meter
+	}
+	return meter
+}
+
+// Rates returns the windowed per-second rates of every metered counter,
+// keyed by counter name and then span.
+func (c *Counters) Rates() map[string]map[string]float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rates := make(map[string]map[string]float64, len(c.meters))
+	for name, meter := range c.meters {
+		rates[name] = meter.Rates()
+	}
+	return rates
+}
+
 func (c *Counters) Get(name string) uint64 {
 	normalized := normalizeName(name)
 
@@ -42,6 +98,9 @@ func (c *Counters) Get(name string) uint64 {
 	return c.values[normalized]
 }
 
+// Snapshot copies every counter. Labeled series are keyed as
+// name{label="value",...}. Gauges, histograms and summaries are not
+// integer counts; Samples includes them along with the counters.
 func (c *Counters) Snapshot() map[string]uint64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -50,31 +109,127 @@ func (c *Counters) Snapshot() map[string]uint64 {
 	for k, v := range c.values {
 		snapshot[k] = v
 	}
+	for name, vec := range c.vecs {
+		for _, series := range vec.Series() {
+			snapshot[formatSeries(name, series.labels)] = series.Get()
+		}
+	}
 
 	return snapshot
 }
 
-// PlainText renders counters in a stable, sorted plain text format.
+// Samples returns the value of every series PlainText renders, keyed the
+// same way: counters and gauges by name, histograms as name_bucket{le=...},
+// name_sum and name_count, and summaries as name{quantile=...}, name_sum
+// and name_count.
+func (c *Counters) Samples() map[string]float64 {
+	return samplesOf(c.Gather())
+}
+
+// PlainText renders every metric in a stable plain text format, one
+// "series value" line each, sorted by metric name.
 func (c *Counters) PlainText() string {
-	snapshot := c.Snapshot()
-	if len(snapshot) == 0 {
+	families := c.Gather()
+	if len(families) == 0 {
 		return "no_counters 0\n"
 	}
 
-	names := make([]string, 0, len(snapshot))
-	for name := range snapshot {
-		names = append(names, name)
-	}
-	sort.Strings(names)
-
 	var b strings.Builder
-	for _, name := range names {
-		fmt.Fprintf(&b, "%s %d\n", name, snapshot[name])
+	for _, family := range families {
+		for _, s := range family.samples() {
+			fmt.Fprintf(&b, "%s %s\n", s.series(), formatValue(s.value))
+		}
 	}
 
 	return b.String()
 }
 
+// SetHelp sets the help text exposition formats print for the metric
+// called name.
+func (c *Counters) SetHelp(name, help string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.help[name] = help
+}
+
+// Collect makes a store a Collector; it is the same as Gather.
+func (c *Counters) Collect() []Family {
+	return c.Gather()
+}
+
+// Gather returns the current state of every metric, sorted by name.
+func (c *Counters) Gather() []Family {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var out []Family
+	add := func(name string, kind Kind, points ...Point) {
+		out = append(out, Family{Name: name, Help: c.help[name], Kind: kind, Points: points})
+	}
+
+	for name, v := range c.values {
+		add(name, KindCounter, Point{Value: float64(v)})
+	}
+	for name, vec := range c.vecs {
+		var points []Point
+		for _, series := range vec.Series() {
+			points = append(points, Point{Labels: series.Labels(), Value: float64(series.Get())})
+		}
+		add(name, KindCounter, points...)
+	}
+	for name, g := range c.gauges {
+		add(name, KindGauge, Point{Value: g.Get()})
+	}
+	for name, h := range c.histograms {
+		snap := h.Snapshot()
+		add(name, KindHistogram, Point{Value: snap.Sum, Histogram: &snap})
+	}
+	for name, s := range c.summaries {
+		snap := s.Snapshot()
+		add(name, KindSummary, Point{Value: snap.Sum, Summary: &snap})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// checkName reports whether name is valid and free for a new metric,
+// plain counters included. The caller holds c.mu.
+func (c *Counters) checkName(name string) error {
+	if !metricNameRE.MatchString(name) {
+		return fmt.Errorf("invalid metric name %q", name)
+	}
+	_, counter := c.values[name]
+	if counter || c.typed(name) {
+		return fmt.Errorf("metric %q already registered as another kind", name)
+	}
+	return nil
+}
+
+// typed reports whether name belongs to a metric other than a plain
+// counter. The caller holds c.mu.
+func (c *Counters) typed(name string) bool {
+	_, vec := c.vecs[name]
+	_, gauge := c.gauges[name]
+	_, histogram := c.histograms[name]
+	_, summary := c.summaries[name]
+	return vec || gauge || histogram || summary
+}
+
+// formatValue renders v as a decimal without exponent, or as +Inf, -Inf or
+// NaN.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
 func normalizeName(name string) string {
 	name = strings.TrimSpace(strings.ToLower(name))
 	if name == "" {
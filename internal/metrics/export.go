@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exporter pushes gathered metrics to a backend. Export must be safe to call
+// from one goroutine at a time; Pusher never calls it concurrently.
+type Exporter interface {
+	Export(ctx context.Context, families []Family) error
+}
+
+// Exporters returns an Exporter that sends to each exporter in turn and
+// joins their errors.
+func Exporters(exporters ...Exporter) Exporter {
+	return multiExporter(exporters)
+}
+
+type multiExporter []Exporter
+
+func (m multiExporter) Export(ctx context.Context, families []Family) error {
+	var errs []error
+	for _, exporter := range m {
+		errs = append(errs, exporter.Export(ctx, families))
+	}
+	return errors.Join(errs...)
+}
+
+// Pusher exports a store's or registry's metrics on an interval and once
+// more when it is stopped, for processes nothing scrapes.
+type Pusher struct {
+	source   Gatherer
+	exporter Exporter
+	interval time.Duration
+	onError  func(error)
+
+	mu      sync.Mutex // serialises exports
+	once    sync.Once
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewPusher returns a pusher exporting source every interval once started;
+// an interval of zero or less only exports on Flush and Stop.
+func NewPusher(source Gatherer, exporter Exporter, interval time.Duration) *Pusher {
+	return &Pusher{
+		source:   source,
+		exporter: exporter,
+		interval: interval,
+		onError:  func(error) {},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// WithErrorHandler sets the function told about failed periodic exports.
+func (p *Pusher) WithErrorHandler(fn func(error)) *Pusher {
+	p.onError = fn
+	return p
+}
+
+// Start begins periodic exports. Each one is given the interval to finish.
+// Later calls do nothing.
+func (p *Pusher) Start() {
+	if p.interval <= 0 || p.started.Swap(true) {
+		return
+	}
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+				if err := p.Flush(ctx); err != nil {
+					p.onError(err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Flush exports the current metrics now.
+func (p *Pusher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exporter.Export(ctx, p.source.Gather())
+}
+
+// Stop ends periodic exports, waits for one in progress and makes a final
+// export. Only the first call exports; later calls return nil.
+func (p *Pusher) Stop(ctx context.Context) error {
+	var err error
+	p.once.Do(func() {
+		close(p.stop)
+		if p.started.Load() {
+			select {
+			case <-p.done:
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			}
+		}
+		err = p.Flush(ctx)
+	})
+	return err
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingExporter keeps the value of one counter at each export.
+type recordingExporter struct {
+	name string
+	err  error
+
+	mu      sync.Mutex
+	exports []float64
+}
+
+func (r *recordingExporter) Export(ctx context.Context, families []Family) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range families {
+		if f.Name == r.name {
+			r.exports = append(r.exports, f.Points[0].Value)
+		}
+	}
+	return r.err
+}
+
+func (r *recordingExporter) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.exports)
+}
+
+func TestPusherPeriodicAndFinalFlush(t *testing.T) {
+	counters := NewCounters()
+	counters.Inc("jobs")
+	exporter := &recordingExporter{name: "jobs"}
+
+	pusher := NewPusher(counters, exporter, 5*time.Millisecond)
+	pusher.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for exporter.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if exporter.count() < 2 {
+		t.Fatal("no periodic exports")
+	}
+
+	counters.Inc("jobs")
+	if err := pusher.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	final := exporter.count()
+	if got := exporter.exports[final-1]; got != 2 {
+		t.Fatalf("final export = %v, want 2", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := pusher.Stop(context.Background()); err != nil || exporter.count() != final {
+		t.Fatalf("exports continued after Stop: %d, want %d", exporter.count(), final)
+	}
+}
+
+func TestPusherWithoutStartFlushesOnStop(t *testing.T) {
+	counters := NewCounters()
+	counters.Inc("jobs")
+	exporter := &recordingExporter{name: "jobs"}
+
+	if err := NewPusher(counters, exporter, 0).Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if exporter.count() != 1 {
+		t.Fatalf("exports = %d, want one final export", exporter.count())
+	}
+}
+
+func TestPusherReportsPeriodicErrors(t *testing.T) {
+	counters := NewCounters()
+	boom := errors.New("backend down")
+	errs := make(chan error, 10)
+
+	pusher := NewPusher(counters, &recordingExporter{err: boom}, time.Millisecond).
+		WithErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+	pusher.Start()
+	defer pusher.Stop(context.Background())
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, boom) {
+			t.Fatalf("error = %v, want %v", err, boom)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("error handler not called")
+	}
+}
+
+func TestExportersJoinsErrors(t *testing.T) {
+	counters := NewCounters()
+	counters.Inc("jobs")
+	ok := &recordingExporter{name: "jobs"}
+	boom := errors.New("boom")
+
+	err := Exporters(&recordingExporter{err: boom}, ok).Export(context.Background(), counters.Gather())
+	if !errors.Is(err, boom) {
+		t.Fatalf("Export() = %v, want %v", err, boom)
+	}
+	if ok.count() != 1 {
+		t.Fatal("later exporter skipped after an error")
+	}
+}
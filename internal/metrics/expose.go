@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is a metrics exposition format.
+type Format int
+
+const (
+	// FormatText is the Prometheus text format, version 0.0.4.
+	FormatText Format = iota
+	// FormatOpenMetrics is OpenMetrics text, version 1.0.0.
+	FormatOpenMetrics
+)
+
+const (
+	ContentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// ContentType is the Content-Type header value for the format.
+func (f Format) ContentType() string {
+	if f == FormatOpenMetrics {
+		return ContentTypeOpenMetrics
+	}
+	return ContentTypeText
+}
+
+// NegotiateFormat picks the format for an HTTP Accept header. OpenMetrics
+// is chosen when the client asks for it at least as strongly as for plain
+// text; anything else, including an empty header, gets the Prometheus text
+// format.
+func NegotiateFormat(accept string) Format {
+	var openMetricsQ, textQ float64
+	for _, mediaRange := range strings.Split(accept, ",") {
+		params := strings.Split(mediaRange, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+
+		q, version := 1.0, ""
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(param, "=")
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "q":
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || parsed < 0 || parsed > 1 {
+					parsed = 0
+				}
+				q = parsed
+			case "version":
+				version = strings.TrimSpace(value)
+			}
+		}
+
+		switch mediaType {
+		case "application/openmetrics-text":
+			if version == "" || version == "1.0.0" || version == "0.0.1" {
+				openMetricsQ = max(openMetricsQ, q)
+			}
+		case "text/plain", "text/*", "*/*":
+			textQ = max(textQ, q)
+		}
+	}
+
+	if openMetricsQ > 0 && openMetricsQ >= textQ {
+		return FormatOpenMetrics
+	}
+	return FormatText
+}
+
+// Expose writes every metric in format, with # HELP (when set with
+// SetHelp) and # TYPE lines before each metric's series.
+//
+// In OpenMetrics a counter's family name drops any _total suffix and its
+// series gain one, so http_requests_total and hello_requests are exposed as
+// families http_requests and hello_requests with series
+// http_requests_total and hello_requests_total. The output ends with
+// "# EOF".
+func (c *Counters) Expose(w io.Writer, format Format) error {
+	return expose(w, c.Gather(), format)
+}
+
+func expose(w io.Writer, families []Family, format Format) error {
+	var b strings.Builder
+	for _, f := range families {
+		name := f.Name
+		if format == FormatOpenMetrics && f.Kind == KindCounter {
+			name = strings.TrimSuffix(name, "_total")
+		}
+
+		if f.Help != "" {
+			b.WriteString("# HELP " + name + " " + escapeHelp(f.Help, format) + "\n")
+		}
+		b.WriteString("# TYPE " + name + " " + string(f.Kind) + "\n")
+		for _, s := range f.samples() {
+			if format == FormatOpenMetrics && f.Kind == KindCounter {
+				s.name = name + "_total"
+			}
+			b.WriteString(s.series() + " " + formatValue(s.value) + "\n")
+		}
+	}
+	if format == FormatOpenMetrics {
+		b.WriteString("# EOF\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var (
+	textHelpEscaper        = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	openMetricsHelpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes help text; OpenMetrics also escapes double quotes.
+func escapeHelp(help string, format Format) string {
+	if format == FormatOpenMetrics {
+		return openMetricsHelpEscaper.Replace(help)
+	}
+	return textHelpEscaper.Replace(help)
+}
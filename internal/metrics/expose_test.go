@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// exposeFixture registers one metric of each kind with known values.
+func exposeFixture() *Counters {
+	counters := NewCounters()
+	counters.Add("http_requests_total", 3)
+	counters.SetHelp("http_requests_total", `Requests received, see C:\docs`+"\nsecond line")
+	counters.Inc("hello_requests")
+
+	vec := counters.MustCounterVec("http_responses_total", "path", "status")
+	counters.SetHelp("http_responses_total", `Responses by "path" and status.`)
+	vec.WithLabels("path", `/say "hi"`+"\n", "status", "200").Add(2)
+
+	counters.MustGauge("in_flight").Set(-1.5)
+
+	h := counters.MustHistogram("latency_seconds", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(5)
+
+	s := counters.MustSummary("check_seconds", map[float64]float64{0.5: 0.05})
+	s.Observe(2)
+
+	return counters
+}
+
+func TestExposeText(t *testing.T) {
+	var b strings.Builder
+	if err := exposeFixture().Expose(&b, FormatText); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# TYPE check_seconds summary
+check_seconds{quantile="0.5"} 2
+check_seconds_sum 2
+check_seconds_count 1
+# TYPE hello_requests counter
+hello_requests 1
+# HELP http_requests_total Requests received, see C:\\docs\nsecond line
+# TYPE http_requests_total counter
+http_requests_total 3
+# HELP http_responses_total Responses by "path" and status.
+# TYPE http_responses_total counter
+http_responses_total{path="/say \"hi\"\n",status="200"} 2
+# TYPE in_flight gauge
+in_flight -1.5
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 1
+latency_seconds_bucket{le="+Inf"} 2
+latency_seconds_sum 5.05
+latency_seconds_count 2
+`
+	if got := b.String(); got != want {
+		t.Fatalf("Expose(FormatText) =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExposeOpenMetrics(t *testing.T) {
+	var b strings.Builder
+	if err := exposeFixture().Expose(&b, FormatOpenMetrics); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# TYPE check_seconds summary
+check_seconds{quantile="0.5"} 2
+check_seconds_sum 2
+check_seconds_count 1
+# TYPE hello_requests counter
+hello_requests_total 1
+# HELP http_requests Requests received, see C:\\docs\nsecond line
+# TYPE http_requests counter
+http_requests_total 3
+# HELP http_responses Responses by \"path\" and status.
+# TYPE http_responses counter
+http_responses_total{path="/say \"hi\"\n",status="200"} 2
+# TYPE in_flight gauge
+in_flight -1.5
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 1
+latency_seconds_bucket{le="+Inf"} 2
+latency_seconds_sum 5.05
+latency_seconds_count 2
+# EOF
+`
+	if got := b.String(); got != want {
+		t.Fatalf("Expose(FormatOpenMetrics) =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExposeEmpty(t *testing.T) {
+	var text, om strings.Builder
+	counters := NewCounters()
+	_ = counters.Expose(&text, FormatText)
+	_ = counters.Expose(&om, FormatOpenMetrics)
+	if text.String() != "" || om.String() != "# EOF\n" {
+		t.Fatalf("empty exposition = %q and %q", text.String(), om.String())
+	}
+}
+
+var (
+	sampleLineRE = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\[\\"n])*",?)*\})? (\S+)$`)
+	metaLineRE   = regexp.MustCompile(`^# (HELP|TYPE) ([a-zA-Z_:][a-zA-Z0-9_:]*) (.*)$`)
+)
+
+// sampleSuffixes lists the series name suffixes each metric kind may use.
+var sampleSuffixes = map[Format]map[Kind][]string{
+	FormatText: {
+		KindCounter:   {""},
+		KindGauge:     {""},
+		KindHistogram: {"_bucket", "_sum", "_count"},
+		KindSummary:   {"", "_sum", "_count"},
+	},
+	FormatOpenMetrics: {
+		KindCounter:   {"_total"},
+		KindGauge:     {""},
+		KindHistogram: {"_bucket", "_sum", "_count"},
+		KindSummary:   {"", "_sum", "_count"},
+	},
+}
+
+// checkExposition validates output against the grammar both formats share:
+// every family is typed once, before its series; series belong to the
+// current family; values parse; and OpenMetrics ends with # EOF.
+func checkExposition(t *testing.T, out string, format Format) {
+	t.Helper()
+
+	seen := map[string]bool{}
+	var family string
+	var kind Kind
+	eof := false
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if eof {
+			t.Fatalf("line after # EOF: %q", line)
+		}
+		if line == "# EOF" && format == FormatOpenMetrics {
+			eof = true
+			continue
+		}
+
+		if m := metaLineRE.FindStringSubmatch(line); m != nil {
+			if m[2] != family {
+				if seen[m[2]] {
+					t.Fatalf("family %q appears twice", m[2])
+				}
+				seen[m[2]] = true
+				family, kind = m[2], ""
+			}
+			if m[1] == "TYPE" {
+				if kind != "" {
+					t.Fatalf("family %q typed twice", family)
+				}
+				kind = Kind(m[3])
+				if _, ok := sampleSuffixes[format][kind]; !ok {
+					t.Fatalf("family %q has unknown type %q", family, kind)
+				}
+			}
+			continue
+		}
+
+		m := sampleLineRE.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("malformed line %q", line)
+		}
+		if kind == "" {
+			t.Fatalf("series %q before its # TYPE line", line)
+		}
+		suffix, ok := strings.CutPrefix(m[1], family)
+		if !ok || !strings.Contains(strings.Join(sampleSuffixes[format][kind], " ")+" ", suffix+" ") {
+			t.Fatalf("series %q does not belong to %s %q", m[1], kind, family)
+		}
+		if _, err := strconv.ParseFloat(m[3], 64); err != nil {
+			t.Fatalf("series %q has bad value %q", m[1], m[3])
+		}
+	}
+	if format == FormatOpenMetrics && !eof {
+		t.Fatal("OpenMetrics output missing # EOF")
+	}
+}
+
+func TestExposeConformance(t *testing.T) {
+	counters := exposeFixture()
+	counters.MustSummary("empty_seconds", nil)
+	counters.MustHistogram("default_seconds", nil).Observe(0.3)
+	vec := counters.MustCounterVec("jobs_total", "queue")
+	vec.SetSeriesLimit(1)
+	vec.WithLabels("queue", `a\b`).Inc()
+	vec.WithLabels("queue", "c").Inc()
+
+	for _, format := range []Format{FormatText, FormatOpenMetrics} {
+		var b strings.Builder
+		if err := counters.Expose(&b, format); err != nil {
+			t.Fatal(err)
+		}
+		checkExposition(t, b.String(), format)
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   Format
+	}{
+		{"", FormatText},
+		{"*/*", FormatText},
+		{"text/plain", FormatText},
+		{"application/json", FormatText},
+		{"application/openmetrics-text", FormatOpenMetrics},
+		{"application/openmetrics-text; version=1.0.0; charset=utf-8", FormatOpenMetrics},
+		{"application/openmetrics-text;version=2.0.0", FormatText},
+		{"application/openmetrics-text;q=0", FormatText},
+		{"text/plain;q=0.9, application/openmetrics-text;q=0.5", FormatText},
+		{"application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1", FormatOpenMetrics},
+		{"Application/OpenMetrics-Text, text/plain", FormatOpenMetrics},
+	}
+	for _, tc := range cases {
+		if got := NegotiateFormat(tc.accept); got != tc.want {
+			t.Errorf("NegotiateFormat(%q) = %v, want %v", tc.accept, got, tc.want)
+		}
+	}
+
+	if FormatOpenMetrics.ContentType() != ContentTypeOpenMetrics || FormatText.ContentType() != ContentTypeText {
+		t.Error("ContentType() does not match the format")
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+// Kind is the type of a metric, as named by the exposition formats.
+type Kind string
+
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+	KindSummary   Kind = "summary"
+)
+
+// Family is the state of one metric at the time it was gathered.
+type Family struct {
+	Name   string
+	Help   string
+	Kind   Kind
+	Points []Point
+}
+
+// Point is one series of a Family. Counters and gauges carry Value;
+// histograms and summaries carry their snapshot, with Value set to its sum.
+type Point struct {
+	Labels    []Label
+	Value     float64
+	Histogram *HistogramSnapshot
+	Summary   *SummarySnapshot
+}
+
+// sample is one rendered series; name includes any suffix such as _bucket.
+type sample struct {
+	name   string
+	labels []Label
+	value  float64
+}
+
+func (s sample) series() string {
+	return formatSeries(s.name, s.labels)
+}
+
+// samples renders the family's series in display order: histograms as
+// name_bucket{le=...}, name_sum and name_count, and summaries as
+// name{quantile=...}, name_sum and name_count.
+func (f Family) samples() []sample {
+	var out []sample
+	for _, p := range f.Points {
+		switch {
+		case p.Histogram != nil:
+			for _, bucket := range p.Histogram.Buckets {
+				le := withLabel(p.Labels, "le", formatValue(bucket.UpperBound))
+				out = append(out, sample{f.Name + "_bucket", le, float64(bucket.Count)})
+			}
+			out = append(out,
+				sample{f.Name + "_sum", p.Labels, p.Histogram.Sum},
+				sample{f.Name + "_count", p.Labels, float64(p.Histogram.Count)},
+			)
+		case p.Summary != nil:
+			for _, q := range p.Summary.Quantiles {
+				quantile := withLabel(p.Labels, "quantile", formatValue(q.Q))
+				out = append(out, sample{f.Name, quantile, q.Value})
+			}
+			out = append(out,
+				sample{f.Name + "_sum", p.Labels, p.Summary.Sum},
+				sample{f.Name + "_count", p.Labels, float64(p.Summary.Count)},
+			)
+		default:
+			out = append(out, sample{f.Name, p.Labels, p.Value})
+		}
+	}
+	return out
+}
+
+func withLabel(labels []Label, name, value string) []Label {
+	return append(append([]Label(nil), labels...), Label{Name: name, Value: value})
+}
+
+func samplesOf(families []Family) map[string]float64 {
+	samples := make(map[string]float64)
+	for _, family := range families {
+		for _, s := range family.samples() {
+			samples[s.series()] = s.value
+		}
+	}
+	return samples
+}
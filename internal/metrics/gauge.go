@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Gauge is a value that can go up and down, such as in-flight requests.
+type Gauge struct {
+	value atomicFloat
+}
+
+// Gauge returns the gauge called name, creating it on first use. It fails
+// if the name is invalid or taken by another kind of metric.
+func (c *Counters) Gauge(name string) (*Gauge, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if g, ok := c.gauges[name]; ok {
+		return g, nil
+	}
+	if err := c.checkName(name); err != nil {
+		return nil, err
+	}
+
+	g := &Gauge{}
+	c.gauges[name] = g
+	return g, nil
+}
+
+// MustGauge is like Gauge but panics on error.
+func (c *Counters) MustGauge(name string) *Gauge {
+	g, err := c.Gauge(name)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+func (g *Gauge) Set(v float64) {
+	g.value.Store(v)
+}
+
+func (g *Gauge) Add(delta float64) float64 {
+	return g.value.Add(delta)
+}
+
+func (g *Gauge) Inc() float64 {
+	return g.Add(1)
+}
+
+func (g *Gauge) Dec() float64 {
+	return g.Add(-1)
+}
+
+func (g *Gauge) Get() float64 {
+	return g.value.Load()
+}
+
+// atomicFloat is a float64 updated with compare-and-swap on its bits.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) Store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) Add(delta float64) float64 {
+	for {
+		old := f.bits.Load()
+		next := math.Float64frombits(old) + delta
+		if f.bits.CompareAndSwap(old, math.Float64bits(next)) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGaugeSetIncDec(t *testing.T) {
+	counters := NewCounters()
+	g := counters.MustGauge("queue_depth")
+
+	g.Set(3)
+	g.Inc()
+	g.Dec()
+	g.Dec()
+	if got := g.Add(0.5); got != 2.5 {
+		t.Fatalf("gauge = %v, want 2.5", got)
+	}
+	if again := counters.MustGauge("queue_depth"); again != g {
+		t.Fatal("MustGauge() returned a new gauge for an existing name")
+	}
+	if text := counters.PlainText(); text != "queue_depth 2.5\n" {
+		t.Fatalf("PlainText() = %q", text)
+	}
+}
+
+func TestGaugeConcurrent(t *testing.T) {
+	g := NewCounters().MustGauge("in_flight")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.Inc()
+				g.Add(0.5)
+				g.Dec()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := g.Get(); got != 2500 {
+		t.Fatalf("gauge = %v, want 2500", got)
+	}
+}
+
+func TestMetricNamesAreExclusive(t *testing.T) {
+	counters := NewCounters()
+	counters.MustGauge("latency_seconds")
+
+	if _, err := counters.Histogram("latency_seconds", nil); err == nil {
+		t.Error("Histogram() reused a gauge's name")
+	}
+	if _, err := counters.Summary("latency_seconds", nil); err == nil {
+		t.Error("Summary() reused a gauge's name")
+	}
+	if _, err := counters.CounterVec("latency_seconds", "path"); err == nil {
+		t.Error("CounterVec() reused a gauge's name")
+	}
+	if _, err := counters.Gauge("bad-name"); err == nil {
+		t.Error("Gauge() accepted an invalid name")
+	}
+}
+
+func TestMetricNamesAreExclusiveWithPlainCounters(t *testing.T) {
+	counters := NewCounters()
+	counters.Inc("requests_total")
+
+	if _, err := counters.Gauge("requests_total"); err == nil {
+		t.Error("Gauge() reused a plain counter's name")
+	}
+	if _, err := counters.Histogram("requests_total", nil); err == nil {
+		t.Error("Histogram() reused a plain counter's name")
+	}
+	if _, err := counters.Summary("requests_total", nil); err == nil {
+		t.Error("Summary() reused a plain counter's name")
+	}
+	if _, err := counters.CounterVec("requests_total", "path"); err == nil {
+		t.Error("CounterVec() reused a plain counter's name")
+	}
+
+	counters.MustGauge("in_flight")
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc() on a gauge's name did not panic")
+		}
+		if strings.Count(counters.PlainText(), "in_flight") != 1 {
+			t.Errorf("PlainText() = %q, want one in_flight series", counters.PlainText())
+		}
+	}()
+	counters.Inc("in_flight")
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// NewGoCollector returns a collector for the Go runtime: goroutines, GC
+// pauses and heap statistics. Each collection briefly stops the world to
+// read memory statistics.
+func NewGoCollector() Collector {
+	return CollectorFunc(collectGo)
+}
+
+func collectGo() []Family {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	gc := debug.GCStats{PauseQuantiles: make([]time.Duration, 5)}
+	debug.ReadGCStats(&gc)
+	pauses := SummarySnapshot{Count: uint64(gc.NumGC), Sum: gc.PauseTotal.Seconds()}
+	for i, pause := range gc.PauseQuantiles {
+		// PauseQuantiles holds the minimum, quartiles and maximum.
+		pauses.Quantiles = append(pauses.Quantiles, Quantile{Q: float64(i) / 4, Value: pause.Seconds()})
+	}
+
+	return []Family{
+		gaugeFamily("go_goroutines", "Goroutines that currently exist.", float64(runtime.NumGoroutine())),
+		{
+			Name: "go_info", Help: "Go version the binary was built with.", Kind: KindGauge,
+			Points: []Point{{Labels: []Label{{Name: "version", Value: runtime.Version()}}, Value: 1}},
+		},
+		{
+			Name: "go_gc_duration_seconds", Help: "Stop-the-world pause durations of garbage collections.", Kind: KindSummary,
+			Points: []Point{{Value: pauses.Sum, Summary: &pauses}},
+		},
+		gaugeFamily("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", float64(mem.HeapAlloc)),
+		gaugeFamily("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.", float64(mem.HeapInuse)),
+		gaugeFamily("go_memstats_heap_objects", "Allocated heap objects.", float64(mem.HeapObjects)),
+		gaugeFamily("go_memstats_heap_sys_bytes", "Bytes of heap memory obtained from the OS.", float64(mem.HeapSys)),
+		gaugeFamily("go_memstats_next_gc_bytes", "Heap size target of the next garbage collection.", float64(mem.NextGC)),
+		counterFamily("go_memstats_alloc_bytes_total", "Bytes allocated for heap objects, including freed ones.", float64(mem.TotalAlloc)),
+		counterFamily("go_memstats_mallocs_total", "Heap objects allocated.", float64(mem.Mallocs)),
+	}
+}
+
+func gaugeFamily(name, help string, value float64) Family {
+	return Family{Name: name, Help: help, Kind: KindGauge, Points: []Point{{Value: value}}}
+}
+
+func counterFamily(name, help string, value float64) Family {
+	return Family{Name: name, Help: help, Kind: KindCounter, Points: []Point{{Value: value}}}
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGoCollector(t *testing.T) {
+	runtime.GC()
+	families := map[string]Family{}
+	for _, f := range NewGoCollector().Collect() {
+		families[f.Name] = f
+	}
+
+	if g := families["go_goroutines"]; g.Kind != KindGauge || g.Points[0].Value < 1 {
+		t.Fatalf("go_goroutines = %+v", g)
+	}
+	if heap := families["go_memstats_heap_alloc_bytes"]; heap.Points[0].Value <= 0 {
+		t.Fatalf("heap alloc = %+v", heap)
+	}
+	if info := families["go_info"]; info.Points[0].Labels[0].Value != runtime.Version() {
+		t.Fatalf("go_info = %+v", info)
+	}
+
+	gc := families["go_gc_duration_seconds"].Points[0].Summary
+	if gc == nil || gc.Count < 1 || len(gc.Quantiles) != 5 || gc.Quantiles[4].Q != 1 {
+		t.Fatalf("go_gc_duration_seconds = %+v, want count >= 1 and min/quartiles/max", gc)
+	}
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultBuckets suit latencies measured in seconds, from 5ms to 10s.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into buckets with fixed upper bounds. An
+// implicit +Inf bucket catches everything above the last bound.
+type Histogram struct {
+	bounds []float64
+	counts []atomic.Uint64 // per bucket, not cumulative; last is +Inf
+	sum    atomicFloat
+}
+
+// Bucket is one cumulative histogram bucket: Count observations were at
+// most UpperBound.
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// HistogramSnapshot is a histogram's state at one point in time. Buckets
+// are cumulative and end with the +Inf bucket.
+type HistogramSnapshot struct {
+	Buckets []Bucket
+	Count   uint64
+	Sum     float64
+}
+
+// Histogram returns the histogram called name, creating it on first use
+// with the given bucket upper bounds; nil means DefaultBuckets. It fails if
+// the name is invalid or taken, the bounds are not increasing, or the
+// histogram already exists with other bounds.
+func (c *Counters) Histogram(name string, buckets []float64) (*Histogram, error) {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	if len(buckets) == 0 {
+		return nil, fmt.Errorf("histogram %q needs at least one bucket", name)
+	}
+	for i, bound := range buckets {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) || (i > 0 && bound <= buckets[i-1]) {
+			return nil, fmt.Errorf("histogram %q: buckets must be finite and strictly increasing, got %v", name, buckets)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if h, ok := c.histograms[name]; ok {
+		if !slices.Equal(h.bounds, buckets) {
+			return nil, fmt.Errorf("histogram %q already registered with buckets %v", name, h.bounds)
+		}
+		return h, nil
+	}
+	if err := c.checkName(name); err != nil {
+		return nil, err
+	}
+
+	h := &Histogram{
+		bounds: append([]float64(nil), buckets...),
+		counts: make([]atomic.Uint64, len(buckets)+1),
+	}
+	c.histograms[name] = h
+	return h, nil
+}
+
+// MustHistogram is like Histogram but panics on error.
+func (c *Counters) MustHistogram(name string, buckets []float64) *Histogram {
+	h, err := c.Histogram(name, buckets)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// Observe records v. NaN is ignored.
+func (h *Histogram) Observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	h.counts[sort.SearchFloat64s(h.bounds, v)].Add(1)
+	h.sum.Add(v)
+}
+
+// Snapshot returns the cumulative buckets, count and sum. Count is the
+// +Inf bucket, as the exposition formats require; a concurrent observation
+// may be in Sum but not yet in the buckets, or the reverse.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	snap := HistogramSnapshot{Buckets: make([]Bucket, len(h.counts))}
+
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		bound := math.Inf(1)
+		if i < len(h.bounds) {
+			bound = h.bounds[i]
+		}
+		snap.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+	}
+	snap.Count = cumulative
+	snap.Sum = h.sum.Load()
+	return snap
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	counters := NewCounters()
+	h := counters.MustHistogram("request_seconds", []float64{0.1, 0.5, 1})
+
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.7, 2, math.NaN()} {
+		h.Observe(v)
+	}
+
+	snap := h.Snapshot()
+	want := []Bucket{{0.1, 2}, {0.5, 3}, {1, 4}, {math.Inf(1), 5}}
+	for i, bucket := range snap.Buckets {
+		if bucket != want[i] {
+			t.Fatalf("bucket %d = %+v, want %+v", i, bucket, want[i])
+		}
+	}
+	if snap.Count != 5 || math.Abs(snap.Sum-3.15) > 1e-9 {
+		t.Fatalf("count, sum = %d, %v; want 5, 3.15", snap.Count, snap.Sum)
+	}
+
+	text := counters.PlainText()
+	wantText := `request_seconds_bucket{le="0.1"} 2
+request_seconds_bucket{le="0.5"} 3
+request_seconds_bucket{le="1"} 4
+request_seconds_bucket{le="+Inf"} 5
+request_seconds_sum 3.15
+request_seconds_count 5
+`
+	if text != wantText {
+		t.Fatalf("PlainText() =\n%s\nwant:\n%s", text, wantText)
+	}
+	if got := counters.Samples()[`request_seconds_bucket{le="+Inf"}`]; got != 5 {
+		t.Fatalf("Samples() +Inf bucket = %v, want 5", got)
+	}
+}
+
+func TestHistogramValidation(t *testing.T) {
+	counters := NewCounters()
+	for _, buckets := range [][]float64{{}, {1, 1}, {2, 1}, {1, math.Inf(1)}} {
+		if _, err := counters.Histogram("bad_seconds", buckets); err == nil {
+			t.Errorf("Histogram(%v) = nil error, want failure", buckets)
+		}
+	}
+
+	counters.MustHistogram("ok_seconds", nil)
+	if _, err := counters.Histogram("ok_seconds", []float64{1}); err == nil {
+		t.Error("Histogram() re-registered with different buckets")
+	}
+}
+
+func TestHistogramConcurrent(t *testing.T) {
+	h := NewCounters().MustHistogram("work_seconds", []float64{1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				h.Observe(float64(j % 2 * 2))
+			}
+		}()
+	}
+	// _count must always equal the +Inf bucket, even mid-observation.
+	for i := 0; i < 100; i++ {
+		if snap := h.Snapshot(); snap.Count != snap.Buckets[len(snap.Buckets)-1].Count {
+			t.Fatalf("count %d != +Inf bucket %d", snap.Count, snap.Buckets[len(snap.Buckets)-1].Count)
+		}
+	}
+	wg.Wait()
+
+	snap := h.Snapshot()
+	if snap.Count != 10000 || snap.Buckets[0].Count != 5000 || snap.Sum != 10000 {
+		t.Fatalf("snapshot = %+v, want 10000 observations, half under 1, sum 10000", snap)
+	}
+}
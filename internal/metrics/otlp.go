@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// otlpScope names this package as the instrumentation scope.
+const otlpScope = "github.com/itprodirect/go-hello-world/internal/metrics"
+
+// OTLP exports metrics to an OpenTelemetry collector with OTLP/HTTP using
+// the JSON encoding. Values are cumulative since the exporter was created.
+type OTLP struct {
+	endpoint string
+	service  string
+	client   *http.Client
+	start    time.Time
+}
+
+// NewOTLP returns an exporter posting to endpoint, e.g.
+// http://localhost:4318; an endpoint without a path gets /v1/metrics.
+// service becomes the service.name resource attribute.
+func NewOTLP(endpoint, service string) (*OTLP, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q (want http(s)://host[:port][/path])", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/metrics"
+	}
+	return &OTLP{
+		endpoint: u.String(),
+		service:  service,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		start:    time.Now(),
+	}, nil
+}
+
+// WithClient sets the HTTP client used for exports.
+func (o *OTLP) WithClient(client *http.Client) *OTLP {
+	o.client = client
+	return o
+}
+
+// Export posts one ExportMetricsServiceRequest holding every family.
+func (o *OTLP) Export(ctx context.Context, families []Family) error {
+	body, err := json.Marshal(o.request(families, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("export OTLP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("export OTLP: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// The types below mirror the protobuf JSON mapping of the OTLP metrics
+// protocol; 64-bit integers are encoded as strings.
+type (
+	otlpRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeMetrics struct {
+		Scope   otlpScopeInfo `json:"scope"`
+		Metrics []otlpMetric  `json:"metrics"`
+	}
+	otlpScopeInfo struct {
+		Name string `json:"name"`
+	}
+	otlpAttribute struct {
+		Key   string        `json:"key"`
+		Value otlpAttrValue `json:"value"`
+	}
+	otlpAttrValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpMetric struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Sum         *otlpSum       `json:"sum,omitempty"`
+		Gauge       *otlpGauge     `json:"gauge,omitempty"`
+		Histogram   *otlpHistogram `json:"histogram,omitempty"`
+		Summary     *otlpSummary   `json:"summary,omitempty"`
+	}
+	otlpSum struct {
+		DataPoints             []otlpNumberPoint `json:"dataPoints"`
+		AggregationTemporality int               `json:"aggregationTemporality"`
+		IsMonotonic            bool              `json:"isMonotonic"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpNumberPoint `json:"dataPoints"`
+	}
+	otlpHistogram struct {
+		DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+		AggregationTemporality int                  `json:"aggregationTemporality"`
+	}
+	otlpSummary struct {
+		DataPoints []otlpSummaryPoint `json:"dataPoints"`
+	}
+	otlpNumberPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		AsDouble          float64         `json:"asDouble"`
+	}
+	otlpHistogramPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		Count             string          `json:"count"`
+		Sum               float64         `json:"sum"`
+		BucketCounts      []string        `json:"bucketCounts"`
+		ExplicitBounds    []float64       `json:"explicitBounds"`
+	}
+	otlpSummaryPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		Count             string          `json:"count"`
+		Sum               float64         `json:"sum"`
+		QuantileValues    []otlpQuantile  `json:"quantileValues"`
+	}
+	otlpQuantile struct {
+		Quantile float64 `json:"quantile"`
+		Value    float64 `json:"value"`
+	}
+)
+
+// aggregationCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const aggregationCumulative = 2
+
+func (o *OTLP) request(families []Family, now time.Time) otlpRequest {
+	start := strconv.FormatInt(o.start.UnixNano(), 10)
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+
+	metrics := make([]otlpMetric, 0, len(families))
+	for _, f := range families {
+		m := otlpMetric{Name: f.Name, Description: f.Help}
+		switch f.Kind {
+		case KindCounter:
+			m.Sum = &otlpSum{AggregationTemporality: aggregationCumulative, IsMonotonic: true}
+			for _, p := range f.Points {
+				m.Sum.DataPoints = append(m.Sum.DataPoints, otlpNumberPoint{
+					Attributes: otlpAttributes(p.Labels), StartTimeUnixNano: start, TimeUnixNano: ts, AsDouble: p.Value,
+				})
+			}
+		case KindGauge:
+			m.Gauge = &otlpGauge{}
+			for _, p := range f.Points {
+				if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+					continue // not representable in JSON
+				}
+				m.Gauge.DataPoints = append(m.Gauge.DataPoints, otlpNumberPoint{
+					Attributes: otlpAttributes(p.Labels), TimeUnixNano: ts, AsDouble: p.Value,
+				})
+			}
+		case KindHistogram:
+			m.Histogram = &otlpHistogram{AggregationTemporality: aggregationCumulative}
+			for _, p := range f.Points {
+				m.Histogram.DataPoints = append(m.Histogram.DataPoints, otlpHistogramDataPoint(p, start, ts))
+			}
+		case KindSummary:
+			m.Summary = &otlpSummary{}
+			for _, p := range f.Points {
+				m.Summary.DataPoints = append(m.Summary.DataPoints, otlpSummaryDataPoint(p, start, ts))
+			}
+		default:
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpAttrValue{StringValue: o.service}},
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScopeInfo{Name: otlpScope}, Metrics: metrics}},
+	}}}
+}
+
+// otlpHistogramDataPoint converts cumulative buckets to OTLP's per-bucket
+// counts; the +Inf bucket has no explicit bound.
+func otlpHistogramDataPoint(p Point, start, ts string) otlpHistogramPoint {
+	h := p.Histogram
+	point := otlpHistogramPoint{
+		Attributes:        otlpAttributes(p.Labels),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Count:             strconv.FormatUint(h.Count, 10),
+		Sum:               h.Sum,
+		BucketCounts:      make([]string, len(h.Buckets)),
+		ExplicitBounds:    make([]float64, 0, len(h.Buckets)),
+	}
+	var previous uint64
+	for i, bucket := range h.Buckets {
+		point.BucketCounts[i] = strconv.FormatUint(bucket.Count-previous, 10)
+		previous = bucket.Count
+		if i < len(h.Buckets)-1 {
+			point.ExplicitBounds = append(point.ExplicitBounds, bucket.UpperBound)
+		}
+	}
+	return point
+}
+
+// otlpSummaryDataPoint leaves out quantiles that have no value yet, since
+// JSON cannot encode NaN.
+func otlpSummaryDataPoint(p Point, start, ts string) otlpSummaryPoint {
+	s := p.Summary
+	point := otlpSummaryPoint{
+		Attributes:        otlpAttributes(p.Labels),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Count:             strconv.FormatUint(s.Count, 10),
+		Sum:               s.Sum,
+		QuantileValues:    []otlpQuantile{},
+	}
+	if s.Count > 0 {
+		for _, q := range s.Quantiles {
+			point.QuantileValues = append(point.QuantileValues, otlpQuantile{Quantile: q.Q, Value: q.Value})
+		}
+	}
+	return point
+}
+
+func otlpAttributes(labels []Label) []otlpAttribute {
+	attrs := make([]otlpAttribute, len(labels))
+	for i, label := range labels {
+		attrs[i] = otlpAttribute{Key: label.Name, Value: otlpAttrValue{StringValue: label.Value}}
+	}
+	return attrs
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOTLPExport(t *testing.T) {
+	var got otlpRequest
+	var path, contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	exporter, err := NewOTLP(srv.URL, "healthcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counters := NewCounters()
+	counters.MustCounterVec("checks_total", "status").WithLabels("status", "up").Add(2)
+	counters.SetHelp("checks_total", "Checks run.")
+	counters.MustGauge("in_flight").Set(1)
+	h := counters.MustHistogram("latency_seconds", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+	counters.MustSummary("check_seconds", map[float64]float64{0.5: 0.05}).Observe(0.2)
+	counters.MustSummary("idle_seconds", nil)
+
+	if err := exporter.Export(context.Background(), counters.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/metrics" || contentType != "application/json" {
+		t.Fatalf("posted to %q as %q", path, contentType)
+	}
+
+	rm := got.ResourceMetrics[0]
+	if attr := rm.Resource.Attributes[0]; attr.Key != "service.name" || attr.Value.StringValue != "healthcheck" {
+		t.Fatalf("resource = %+v", rm.Resource)
+	}
+	metrics := map[string]otlpMetric{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	sum := metrics["checks_total"].Sum
+	if sum == nil || !sum.IsMonotonic || sum.AggregationTemporality != aggregationCumulative || metrics["checks_total"].Description != "Checks run." {
+		t.Fatalf("checks_total = %+v", metrics["checks_total"])
+	}
+	if p := sum.DataPoints[0]; p.AsDouble != 2 || p.Attributes[0].Key != "status" || p.Attributes[0].Value.StringValue != "up" {
+		t.Fatalf("checks_total point = %+v", p)
+	}
+	if g := metrics["in_flight"].Gauge; g == nil || g.DataPoints[0].AsDouble != 1 {
+		t.Fatalf("in_flight = %+v", metrics["in_flight"])
+	}
+
+	hp := metrics["latency_seconds"].Histogram.DataPoints[0]
+	if hp.Count != "3" || strings.Join(hp.BucketCounts, ",") != "1,1,1" || len(hp.ExplicitBounds) != 2 {
+		t.Fatalf("histogram point = %+v", hp)
+	}
+	sp := metrics["check_seconds"].Summary.DataPoints[0]
+	if sp.Count != "1" || len(sp.QuantileValues) != 1 || sp.QuantileValues[0].Value != 0.2 {
+		t.Fatalf("summary point = %+v", sp)
+	}
+	if idle := metrics["idle_seconds"].Summary.DataPoints[0]; len(idle.QuantileValues) != 0 {
+		t.Fatalf("empty summary sent quantiles %+v", idle.QuantileValues)
+	}
+}
+
+func TestOTLPExportErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	exporter, err := NewOTLP(srv.URL+"/otlp/v1/metrics", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exporter.Export(context.Background(), NewCounters().Gather())
+	if err == nil || !strings.Contains(err.Error(), "429") || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("Export() = %v, want 429 with body", err)
+	}
+}
+
+func TestNewOTLPRejectsBadEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"", "localhost:4318", "ftp://collector", "http://"} {
+		if _, err := NewOTLP(endpoint, "test"); err == nil {
+			t.Errorf("NewOTLP(%q) = nil error", endpoint)
+		}
+	}
+}
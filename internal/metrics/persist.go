@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot file format.
+const snapshotVersion = 1
+
+// snapshotDoc is the JSON layout of a snapshot file.
+type snapshotDoc struct {
+	Version  int               `json:"version"`
+	SavedAt  time.Time         `json:"saved_at"`
+	Counters []snapshotCounter `json:"counters"`
+}
+
+type snapshotCounter struct {
+	Name   string          `json:"name"`
+	Labels []snapshotLabel `json:"labels,omitempty"`
+	Value  uint64          `json:"value"`
+}
+
+type snapshotLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// SnapshotFile is an Exporter that saves counters to a JSON file, so a
+// Pusher can snapshot periodically and on shutdown. Counters.Restore reads
+// the file back.
+type SnapshotFile struct {
+	path  string
+	names []string
+	now   func() time.Time
+}
+
+// NewSnapshotFile returns an exporter writing to path. Only the named
+// counters and counter vectors are saved; no names saves all of them.
+// Gauges, histograms, summaries and a vector's overflow series are never
+// saved.
+func NewSnapshotFile(path string, names ...string) *SnapshotFile {
+	return &SnapshotFile{path: path, names: names, now: time.Now}
+}
+
+// Export replaces the file atomically: the snapshot is written to a
+// temporary file in the same directory, synced and renamed over the old
+// one, so readers and a crash mid-write see either snapshot whole.
+func (s *SnapshotFile) Export(ctx context.Context, families []Family) error {
+	doc := snapshotDoc{Version: snapshotVersion, SavedAt: s.now().UTC(), Counters: []snapshotCounter{}}
+	for _, f := range families {
+		if f.Kind != KindCounter || !selected(s.names, f.Name) {
+			continue
+		}
+		for _, p := range f.Points {
+			if isOverflow(p.Labels) {
+				continue
+			}
+			counter := snapshotCounter{Name: f.Name, Value: uint64(p.Value)}
+			for _, label := range p.Labels {
+				counter.Labels = append(counter.Labels, snapshotLabel(label))
+			}
+			doc.Counters = append(doc.Counters, counter)
+		}
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, append(data, '\n'))
+}
+
+// Restore sets counters from a snapshot file written by SnapshotFile,
+// keeping only the named ones (no names keeps all). A missing file is not
+// an error. Restored values replace current ones and are not seen by
+// meters, so restore before serving traffic.
+func (c *Counters) Restore(path string, names ...string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var doc snapshotDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse snapshot %s: %w", path, err)
+	}
+	if doc.Version != snapshotVersion {
+		return fmt.Errorf("snapshot %s: unsupported version %d", path, doc.Version)
+	}
+
+	for _, counter := range doc.Counters {
+		if !selected(names, counter.Name) {
+			continue
+		}
+		if len(counter.Labels) == 0 {
+			name := normalizeName(counter.Name)
+			c.mu.Lock()
+			if c.typed(name) {
+				c.mu.Unlock()
+				return fmt.Errorf("snapshot %s: counter %q already registered as another kind", path, name)
+			}
+			c.values[name] = counter.Value
+			c.mu.Unlock()
+			continue
+		}
+
+		labelNames := make([]string, len(counter.Labels))
+		pairs := make([]string, 0, 2*len(counter.Labels))
+		for i, label := range counter.Labels {
+			labelNames[i] = label.Name
+			pairs = append(pairs, label.Name, label.Value)
+		}
+		vec, err := c.CounterVec(counter.Name, labelNames...)
+		if err != nil {
+			return fmt.Errorf("snapshot %s: %w", path, err)
+		}
+		vec.WithLabels(pairs...).value.Store(counter.Value)
+	}
+	return nil
+}
+
+func selected(names []string, name string) bool {
+	return len(names) == 0 || slices.Contains(names, name)
+}
+
+func isOverflow(labels []Label) bool {
+	for _, label := range labels {
+		if label.Value != OverflowLabelValue {
+			return false
+		}
+	}
+	return len(labels) > 0
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
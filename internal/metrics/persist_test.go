@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+
+	counters := NewCounters()
+	counters.Add("hello_requests", 41)
+	counters.Add("uptime_ticks", 7)
+	vec := counters.MustCounterVec("http_responses_total", "path", "status")
+	vec.SetSeriesLimit(1)
+	vec.WithLabels("path", `/say "hi"`, "status", "200").Add(3)
+	vec.WithLabels("path", "/other", "status", "200").Inc() // overflow
+	counters.MustGauge("in_flight").Set(2)
+
+	if err := NewSnapshotFile(path).Export(context.Background(), counters.Gather()); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewCounters()
+	if err := restored.Restore(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Inc("hello_requests"); got != 42 {
+		t.Fatalf("hello_requests after restore and Inc = %d, want 42", got)
+	}
+	got := restored.Snapshot()
+	if got["uptime_ticks"] != 7 || got[`http_responses_total{path="/say \"hi\"",status="200"}`] != 3 {
+		t.Fatalf("restored snapshot = %v", got)
+	}
+	if len(restored.MustCounterVec("http_responses_total", "path", "status").Series()) != 1 {
+		t.Fatal("overflow series was persisted")
+	}
+	if _, ok := restored.Samples()["in_flight"]; ok {
+		t.Fatal("gauge was persisted")
+	}
+}
+
+func TestSnapshotFileSelectsCounters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+
+	counters := NewCounters()
+	counters.Add("hello_requests", 5)
+	counters.Add("metrics_requests", 9)
+	if err := NewSnapshotFile(path, "hello_requests").Export(context.Background(), counters.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "metrics_requests") {
+		t.Fatalf("unselected counter saved:\n%s", data)
+	}
+
+	// Restore applies its own selection too.
+	all := NewCounters()
+	all.Restore(path)
+	none := NewCounters()
+	none.Restore(path, "uptime_ticks")
+	if all.Get("hello_requests") != 5 || none.Get("hello_requests") != 0 {
+		t.Fatalf("restore selection: all=%d none=%d", all.Get("hello_requests"), none.Get("hello_requests"))
+	}
+}
+
+func TestSnapshotFileReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "counters.json")
+	counters := NewCounters()
+	snapshots := NewSnapshotFile(path)
+
+	for i := 0; i < 3; i++ {
+		counters.Inc("jobs")
+		if err := snapshots.Export(context.Background(), counters.Gather()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("directory holds %d files, want no temporary files left", len(entries))
+	}
+	restored := NewCounters()
+	if err := restored.Restore(path); err != nil || restored.Get("jobs") != 3 {
+		t.Fatalf("Restore() = %v, jobs = %d; want 3", err, restored.Get("jobs"))
+	}
+}
+
+func TestRestoreMissingAndCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	counters := NewCounters()
+	if err := counters.Restore(filepath.Join(dir, "missing.json")); err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"corrupt.json": "{not json",
+		"future.json":  `{"version": 99, "counters": []}`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		if err := counters.Restore(path); err == nil {
+			t.Errorf("Restore(%s) = nil, want error", name)
+		}
+	}
+}
+
+func TestRestoreDoesNotMarkMeters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	saved := NewCounters()
+	saved.Add("http_requests_total", 1000)
+	NewSnapshotFile(path).Export(context.Background(), saved.Gather())
+
+	counters := NewCounters()
+	meter := counters.Meter("http_requests_total")
+	counters.Restore(path)
+	if sum, _ := meter.windows[0].Sum(); sum != 0 {
+		t.Fatalf("restore marked the meter with %v", sum)
+	}
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc; it is 100 on every
+// mainstream Linux platform.
+const clockTicks = 100
+
+// NewProcessCollector returns a collector for this process read from Linux
+// /proc: resident and virtual memory, open and maximum file descriptors,
+// CPU time and start time. Values that cannot be read, as on systems
+// without /proc, are left out.
+func NewProcessCollector() Collector {
+	return &processCollector{proc: "/proc", pid: "self", pageSize: os.Getpagesize()}
+}
+
+type processCollector struct {
+	proc     string // procfs mount point
+	pid      string
+	pageSize int
+}
+
+func (p *processCollector) Collect() []Family {
+	var out []Family
+
+	if stat, err := p.readStat(); err == nil {
+		out = append(out,
+			counterFamily("process_cpu_seconds_total", "User and system CPU time spent, in seconds.", stat.cpuSeconds),
+			gaugeFamily("process_resident_memory_bytes", "Resident memory size in bytes.", float64(stat.rssPages*p.pageSize)),
+			gaugeFamily("process_virtual_memory_bytes", "Virtual memory size in bytes.", stat.virtualBytes),
+		)
+		if boot, err := p.bootTime(); err == nil {
+			out = append(out, gaugeFamily("process_start_time_seconds", "Start time of the process since the Unix epoch, in seconds.",
+				boot+stat.startTicks/clockTicks))
+		}
+	}
+
+	if fds, err := os.ReadDir(filepath.Join(p.proc, p.pid, "fd")); err == nil {
+		out = append(out, gaugeFamily("process_open_fds", "Open file descriptors.", float64(len(fds))))
+	}
+	if limit, err := p.maxFDs(); err == nil {
+		out = append(out, gaugeFamily("process_max_fds", "Maximum number of open file descriptors.", limit))
+	}
+
+	return out
+}
+
+type procStat struct {
+	cpuSeconds   float64
+	startTicks   float64
+	virtualBytes float64
+	rssPages     int
+}
+
+// readStat parses /proc/<pid>/stat. The command name in field 2 may contain
+// spaces and parentheses, so fields are counted from the last ')'.
+func (p *processCollector) readStat() (procStat, error) {
+	data, err := os.ReadFile(filepath.Join(p.proc, p.pid, "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}, errors.New("malformed stat")
+	}
+	// fields[0] is field 3 (state).
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 22 {
+		return procStat{}, errors.New("short stat")
+	}
+
+	num := func(field int) float64 {
+		v, _ := strconv.ParseFloat(fields[field-3], 64)
+		return v
+	}
+	return procStat{
+		cpuSeconds:   (num(14) + num(15)) / clockTicks,
+		startTicks:   num(22),
+		virtualBytes: num(23),
+		rssPages:     int(num(24)),
+	}, nil
+}
+
+// bootTime reads the btime line of /proc/stat.
+func (p *processCollector) bootTime() (float64, error) {
+	f, err := os.Open(filepath.Join(p.proc, "stat"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			return strconv.ParseFloat(strings.TrimSpace(rest), 64)
+		}
+	}
+	return 0, errors.New("no btime in stat")
+}
+
+// maxFDs reads the soft limit from the "Max open files" line of
+// /proc/<pid>/limits.
+func (p *processCollector) maxFDs() (float64, error) {
+	f, err := os.Open(filepath.Join(p.proc, p.pid, "limits"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(scanner.Text(), "Max open files"); ok {
+			fields := strings.Fields(rest)
+			if len(fields) == 0 || fields[0] == "unlimited" {
+				break
+			}
+			return strconv.ParseFloat(fields[0], 64)
+		}
+	}
+	return 0, errors.New("no open files limit")
+}
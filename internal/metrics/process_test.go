@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcessCollectorParsesProc(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// utime 150 + stime 50 ticks, starttime 1000 ticks, vsize, rss 25 pages.
+	write("42/stat", "42 (hello (server) x) S 1 42 42 0 -1 4194560 500 0 0 0 150 50 0 0 20 0 8 0 1000 123456789 25 18446744073709551615 0 0\n")
+	write("stat", "cpu  1 2 3 4\nbtime 1700000000\nprocesses 10\n")
+	write("42/limits", "Limit                     Soft Limit           Hard Limit           Units\nMax open files            1024                 4096                 files\n")
+	write("42/fd/0", "")
+	write("42/fd/1", "")
+	write("42/fd/2", "")
+
+	collector := &processCollector{proc: root, pid: "42", pageSize: 4096}
+	got := map[string]float64{}
+	for _, f := range collector.Collect() {
+		got[f.Name] = f.Points[0].Value
+	}
+
+	want := map[string]float64{
+		"process_cpu_seconds_total":     2,
+		"process_resident_memory_bytes": 25 * 4096,
+		"process_virtual_memory_bytes":  123456789,
+		"process_start_time_seconds":    1700000010,
+		"process_open_fds":              3,
+		"process_max_fds":               1024,
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %v, want %v", name, got[name], value)
+		}
+	}
+}
+
+func TestProcessCollectorWithoutProc(t *testing.T) {
+	collector := &processCollector{proc: filepath.Join(t.TempDir(), "missing"), pid: "self", pageSize: 4096}
+	if families := collector.Collect(); len(families) != 0 {
+		t.Fatalf("Collect() = %+v, want nothing without /proc", families)
+	}
+}
+
+func TestProcessCollectorLive(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc on this system")
+	}
+	got := map[string]float64{}
+	for _, f := range NewProcessCollector().Collect() {
+		got[f.Name] = f.Points[0].Value
+	}
+	if got["process_resident_memory_bytes"] <= 0 || got["process_open_fds"] < 1 {
+		t.Fatalf("live process metrics = %v", got)
+	}
+}
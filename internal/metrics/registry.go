@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Collector produces metric families when a Registry is gathered, so its
+// values are read at scrape or push time.
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc adapts a function to Collector.
+type CollectorFunc func() []Family
+
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+// Gatherer is anything whose metrics can be gathered: a Counters store or a
+// Registry.
+type Gatherer interface {
+	Gather() []Family
+}
+
+// Registry combines named collectors into one set of metrics. Counters
+// stores are collectors too.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// Register adds c under name. It fails if the name is taken.
+func (r *Registry) Register(name string, c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[name]; ok {
+		return fmt.Errorf("collector %q already registered", name)
+	}
+	r.collectors[name] = c
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func (r *Registry) MustRegister(name string, c Collector) {
+	if err := r.Register(name, c); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes the collector called name and reports whether it was
+// registered.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.collectors[name]
+	delete(r.collectors, name)
+	return ok
+}
+
+// Gather collects from every collector, in collector-name order, and
+// returns the families sorted by name. Family names should be unique across
+// collectors; when two collectors produce the same one, the first
+// collector's wins.
+func (r *Registry) Gather() []Family {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]Collector, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		collectors[i] = r.collectors[name]
+	}
+	r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var out []Family
+	for _, c := range collectors {
+		for _, f := range c.Collect() {
+			if seen[f.Name] {
+				continue
+			}
+			seen[f.Name] = true
+			out = append(out, f)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Expose writes every collector's metrics in format; see Counters.Expose.
+func (r *Registry) Expose(w io.Writer, format Format) error {
+	return expose(w, r.Gather(), format)
+}
+
+// Samples returns the value of every series, keyed as in
+// Counters.Samples.
+func (r *Registry) Samples() map[string]float64 {
+	return samplesOf(r.Gather())
+}
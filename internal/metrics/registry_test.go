@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryGathersAtScrapeTime(t *testing.T) {
+	registry := NewRegistry()
+	counters := NewCounters()
+	counters.Inc("jobs")
+	registry.MustRegister("app", counters)
+
+	calls := 0
+	registry.MustRegister("custom", CollectorFunc(func() []Family {
+		calls++
+		return []Family{gaugeFamily("custom_calls", "", float64(calls))}
+	}))
+
+	registry.Gather()
+	families := registry.Gather()
+	if len(families) != 2 || families[0].Name != "custom_calls" || families[1].Name != "jobs" {
+		t.Fatalf("Gather() = %+v, want custom_calls and jobs sorted", families)
+	}
+	if got := families[0].Points[0].Value; got != 2 {
+		t.Fatalf("custom_calls = %v, want the collector run on each gather", got)
+	}
+}
+
+func TestRegistryRegisterAndUnregister(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister("go", NewGoCollector())
+	if err := registry.Register("go", NewGoCollector()); err == nil {
+		t.Fatal("Register() accepted a duplicate name")
+	}
+	if !registry.Unregister("go") || registry.Unregister("go") {
+		t.Fatal("Unregister() should report removal once")
+	}
+	if families := registry.Gather(); len(families) != 0 {
+		t.Fatalf("Gather() after Unregister = %+v", families)
+	}
+}
+
+func TestRegistryDuplicateFamilyFirstCollectorWins(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister("a", CollectorFunc(func() []Family { return []Family{gaugeFamily("dup", "", 1)} }))
+	registry.MustRegister("b", CollectorFunc(func() []Family { return []Family{gaugeFamily("dup", "", 2)} }))
+
+	families := registry.Gather()
+	if len(families) != 1 || families[0].Points[0].Value != 1 {
+		t.Fatalf("Gather() = %+v, want collector a's family only", families)
+	}
+}
+
+func TestRegistryExpose(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister("app", exposeFixture())
+	registry.MustRegister("go", NewGoCollector())
+	registry.MustRegister("process", NewProcessCollector())
+
+	for _, format := range []Format{FormatText, FormatOpenMetrics} {
+		var b strings.Builder
+		if err := registry.Expose(&b, format); err != nil {
+			t.Fatal(err)
+		}
+		checkExposition(t, b.String(), format)
+		if !strings.Contains(b.String(), "# TYPE go_goroutines gauge\n") || !strings.Contains(b.String(), "in_flight -1.5\n") {
+			t.Fatalf("exposition missing app or runtime metrics:\n%s", b.String())
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// StatsDFlavor selects how a StatsD exporter encodes labels.
+type StatsDFlavor int
+
+const (
+	// StatsDPlain folds label values into the metric name, separated by dots.
+	StatsDPlain StatsDFlavor = iota
+	// DogStatsD sends labels as |#name:value tags.
+	DogStatsD
+)
+
+// maxStatsDPacket keeps each datagram within a typical network MTU.
+const maxStatsDPacket = 1432
+
+// StatsD exports metrics over UDP in the StatsD line protocol. Counters,
+// histogram buckets and the _sum and _count of histograms and summaries
+// are sent as |c deltas since the previous export; gauges and summary
+// quantiles as |g values.
+type StatsD struct {
+	conn   net.Conn
+	flavor StatsDFlavor
+	prefix string
+
+	mu   sync.Mutex
+	last map[string]float64 // cumulative value per series at the last export
+}
+
+// DialStatsD returns an exporter sending to the StatsD agent at addr
+// (host:port).
+func DialStatsD(addr string, flavor StatsDFlavor) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial statsd %s: %w", addr, err)
+	}
+	return &StatsD{conn: conn, flavor: flavor, last: make(map[string]float64)}, nil
+}
+
+// WithPrefix prepends prefix and a dot to every metric name.
+func (s *StatsD) WithPrefix(prefix string) *StatsD {
+	s.prefix = prefix
+	return s
+}
+
+// Export sends one line per changed series, batched into datagrams. Deltas
+// are only committed once every datagram was written.
+func (s *StatsD) Export(ctx context.Context, families []Family) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lines []string
+	next := make(map[string]float64)
+	for _, f := range families {
+		for _, sample := range f.samples() {
+			key := sample.series()
+			name, tags := s.encode(sample)
+
+			if f.Kind == KindGauge || (f.Kind == KindSummary && sample.name == f.Name) {
+				// A leading sign makes a gauge value relative, so negative
+				// values are sent as a reset to zero followed by a decrement.
+				if sample.value < 0 {
+					lines = append(lines, name+":0|g"+tags)
+				}
+				lines = append(lines, name+":"+formatValue(sample.value)+"|g"+tags)
+				continue
+			}
+
+			next[key] = sample.value
+			delta := sample.value - s.last[key]
+			if delta < 0 {
+				delta = sample.value // the series was reset
+			}
+			if delta != 0 {
+				lines = append(lines, name+":"+formatValue(delta)+"|c"+tags)
+			}
+		}
+	}
+
+	for _, packet := range packLines(lines, maxStatsDPacket) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := s.conn.Write([]byte(packet)); err != nil {
+			return fmt.Errorf("write statsd: %w", err)
+		}
+	}
+	s.last = next
+	return nil
+}
+
+// Close closes the UDP socket.
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+var (
+	statsdNameReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", "\n", "_", " ", "_", "/", "_")
+	statsdTagReplacer  = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+)
+
+// encode returns the StatsD name of a sample and its DogStatsD tag suffix.
+func (s *StatsD) encode(sample sample) (name, tags string) {
+	name = sample.name
+	if s.prefix != "" {
+		name = s.prefix + "." + name
+	}
+
+	if s.flavor == DogStatsD {
+		parts := make([]string, len(sample.labels))
+		for i, label := range sample.labels {
+			parts[i] = label.Name + ":" + statsdTagReplacer.Replace(label.Value)
+		}
+		if len(parts) > 0 {
+			tags = "|#" + strings.Join(parts, ",")
+		}
+		return statsdNameReplacer.Replace(name), tags
+	}
+
+	for _, label := range sample.labels {
+		name += "." + strings.ReplaceAll(label.Value, ".", "_")
+	}
+	return statsdNameReplacer.Replace(name), ""
+}
+
+// packLines joins lines with newlines into packets of at most size bytes; a
+// longer line gets a packet of its own.
+func packLines(lines []string, size int) []string {
+	var packets []string
+	var b strings.Builder
+	for _, line := range lines {
+		if b.Len() > 0 && b.Len()+1+len(line) > size {
+			packets = append(packets, b.String())
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+	}
+	if b.Len() > 0 {
+		packets = append(packets, b.String())
+	}
+	return packets
+}
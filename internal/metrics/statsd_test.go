@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenUDP returns a local UDP receiver and a function reading the lines
+// of every datagram that arrives within a short wait.
+func listenUDP(t *testing.T) (string, func() []string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	read := func() []string {
+		var lines []string
+		buf := make([]byte, 65536)
+		for {
+			_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return lines
+			}
+			if n > maxStatsDPacket && strings.Contains(string(buf[:n]), "\n") {
+				t.Errorf("datagram of %d bytes exceeds %d", n, maxStatsDPacket)
+			}
+			lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+		}
+	}
+	return conn.LocalAddr().String(), read
+}
+
+func TestStatsDPlain(t *testing.T) {
+	addr, read := listenUDP(t)
+	exporter, err := DialStatsD(addr, StatsDPlain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+	exporter.WithPrefix("app")
+
+	counters := NewCounters()
+	counters.Add("jobs", 3)
+	counters.MustCounterVec("responses_total", "path", "status").WithLabels("path", "/hello", "status", "200").Inc()
+	counters.MustGauge("temperature").Set(-2.5)
+	counters.MustHistogram("latency_seconds", []float64{0.1}).Observe(0.05)
+
+	if err := exporter.Export(context.Background(), counters.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	got := read()
+	want := []string{
+		"app.jobs:3|c",
+		"app.latency_seconds_bucket.0_1:1|c",
+		"app.latency_seconds_bucket.+Inf:1|c",
+		"app.latency_seconds_sum:0.05|c",
+		"app.latency_seconds_count:1|c",
+		"app.responses_total._hello.200:1|c",
+		"app.temperature:0|g",
+		"app.temperature:-2.5|g",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("lines =\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	counters.Add("jobs", 2)
+	if err := exporter.Export(context.Background(), counters.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	got = read()
+	want = []string{"app.jobs:2|c", "app.temperature:0|g", "app.temperature:-2.5|g"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("second export sent %q, want only deltas and gauges %q", got, want)
+	}
+}
+
+func TestStatsDDogStatsDTags(t *testing.T) {
+	addr, read := listenUDP(t)
+	exporter, err := DialStatsD(addr, DogStatsD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+
+	counters := NewCounters()
+	counters.MustCounterVec("responses_total", "path", "status").WithLabels("path", "/a,b|c", "status", "200").Add(4)
+	counters.MustSummary("check_seconds", map[float64]float64{0.5: 0.05}).Observe(0.2)
+
+	if err := exporter.Export(context.Background(), counters.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	got := read()
+	want := []string{
+		"check_seconds:0.2|g|#quantile:0.5",
+		"check_seconds_sum:0.2|c",
+		"check_seconds_count:1|c",
+		"responses_total:4|c|#path:/a_b_c,status:200",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("lines =\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStatsDSplitsPackets(t *testing.T) {
+	addr, read := listenUDP(t)
+	exporter, err := DialStatsD(addr, StatsDPlain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+
+	counters := NewCounters()
+	vec := counters.MustCounterVec("requests_total", "id")
+	for i := 0; i < 200; i++ {
+		vec.WithLabels("id", strings.Repeat("x", 10)+string(rune('a'+i%26))+strings.Repeat("y", i%7)).Inc()
+	}
+
+	if err := exporter.Export(context.Background(), counters.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(read()), len(vec.Series()); got != want {
+		t.Fatalf("received %d lines, want %d", got, want)
+	}
+}
@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// DefaultObjectives are the quantiles a Summary tracks when none are given,
+// mapped to their allowed rank error.
+var DefaultObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+// summaryBufferSize is how many observations a Summary buffers before
+// merging them into its stream.
+const summaryBufferSize = 500
+
+// Summary estimates quantiles of all observations in bounded memory, using
+// the targeted-quantiles algorithm of Cormode, Korn, Muthukrishnan and
+// Srivastava. Each quantile q is accurate to a rank error of its
+// objective's epsilon, i.e. the reported value has a true rank within
+// (q±epsilon)·count.
+type Summary struct {
+	objectives []Quantile // Value holds the epsilon
+
+	mu      sync.Mutex
+	buffer  []float64
+	samples []ckmsSample
+	n       float64
+	count   uint64
+	sum     float64
+}
+
+// Quantile is one estimated quantile: Value is the Q-quantile.
+type Quantile struct {
+	Q     float64
+	Value float64
+}
+
+// SummarySnapshot is a summary's state at one point in time. Quantiles are
+// sorted by Q; their values are NaN before the first observation.
+type SummarySnapshot struct {
+	Quantiles []Quantile
+	Count     uint64
+	Sum       float64
+}
+
+// ckmsSample stands for width observations ending at value; delta bounds
+// the uncertainty of its rank.
+type ckmsSample struct {
+	value float64
+	width float64
+	delta float64
+}
+
+// Summary returns the summary called name, creating it on first use with
+// objectives mapping each quantile to its allowed error; nil means
+// DefaultObjectives. It fails if the name is invalid or taken, an
+// objective is out of range, or the summary already exists with other
+// objectives.
+func (c *Counters) Summary(name string, objectives map[float64]float64) (*Summary, error) {
+	if objectives == nil {
+		objectives = DefaultObjectives
+	}
+	if len(objectives) == 0 {
+		return nil, fmt.Errorf("summary %q needs at least one objective", name)
+	}
+	targets := make([]Quantile, 0, len(objectives))
+	for q, epsilon := range objectives {
+		if !(q > 0 && q < 1) || !(epsilon > 0 && epsilon < 1) {
+			return nil, fmt.Errorf("summary %q: objective %v±%v out of range (0, 1)", name, q, epsilon)
+		}
+		targets = append(targets, Quantile{Q: q, Value: epsilon})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Q < targets[j].Q })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.summaries[name]; ok {
+		if !slices.Equal(s.objectives, targets) {
+			return nil, fmt.Errorf("summary %q already registered with other objectives", name)
+		}
+		return s, nil
+	}
+	if err := c.checkName(name); err != nil {
+		return nil, err
+	}
+
+	s := &Summary{objectives: targets}
+	c.summaries[name] = s
+	return s, nil
+}
+
+// MustSummary is like Summary but panics on error.
+func (c *Counters) MustSummary(name string, objectives map[float64]float64) *Summary {
+	s, err := c.Summary(name, objectives)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Observe records v. NaN is ignored.
+func (s *Summary) Observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+	s.sum += v
+	s.buffer = append(s.buffer, v)
+	if len(s.buffer) >= summaryBufferSize {
+		s.flush()
+	}
+}
+
+// Snapshot returns the estimated quantiles, count and sum.
+func (s *Summary) Snapshot() SummarySnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flush()
+	snap := SummarySnapshot{
+		Quantiles: make([]Quantile, len(s.objectives)),
+		Count:     s.count,
+		Sum:       s.sum,
+	}
+	for i, objective := range s.objectives {
+		snap.Quantiles[i] = Quantile{Q: objective.Q, Value: s.query(objective.Q)}
+	}
+	return snap
+}
+
+// invariant is the most rank uncertainty (width plus delta) a sample may
+// have when the samples before it end at rank r. For each objective it
+// shrinks linearly towards the quantile's rank, so a sample can never
+// straddle the whole band (q±epsilon)·n, and because it only grows as
+// observations arrive, samples merged earlier keep satisfying it.
+func (s *Summary) invariant(r float64) float64 {
+	limit := math.MaxFloat64
+	for _, objective := range s.objectives {
+		q, epsilon := objective.Q, min(objective.Value, 1-objective.Q)
+		var f float64
+		if q*s.n <= r {
+			f = epsilon * r / q
+		} else {
+			f = epsilon * (s.n - r) / (1 - q)
+		}
+		limit = min(limit, f)
+	}
+	return limit
+}
+
+// flush merges the buffered observations into the samples and compresses
+// them.
+func (s *Summary) flush() {
+	if len(s.buffer) == 0 {
+		return
+	}
+	sort.Float64s(s.buffer)
+
+	merged := make([]ckmsSample, 0, len(s.samples)+len(s.buffer))
+	i := 0
+	for _, v := range s.buffer {
+		for ; i < len(s.samples) && s.samples[i].value <= v; i++ {
+			merged = append(merged, s.samples[i])
+		}
+		// A new minimum or maximum has an exact rank; anything else is
+		// at most as uncertain as the sample it is inserted before.
+		delta := 0.0
+		if len(merged) > 0 && i < len(s.samples) {
+			delta = s.samples[i].width + s.samples[i].delta - 1
+		}
+		merged = append(merged, ckmsSample{value: v, width: 1, delta: delta})
+	}
+	s.samples = append(merged, s.samples[i:]...)
+	s.n += float64(len(s.buffer))
+	s.buffer = s.buffer[:0]
+	s.compress()
+}
+
+// compress folds samples into their successors wherever the invariant
+// allows, walking from the top rank down. The minimum is never folded, so
+// it and the maximum stay exact.
+func (s *Summary) compress() {
+	if len(s.samples) < 3 {
+		return
+	}
+
+	// Kept samples are written from the end of the slice downwards; x is
+	// the one being folded into and rank its minimum rank.
+	xi := len(s.samples) - 1
+	rank := s.n
+	for i := len(s.samples) - 2; i >= 1; i-- {
+		c := s.samples[i]
+		x := s.samples[xi]
+		start := rank - x.width - c.width
+		if c.width+x.width+x.delta <= s.invariant(start) {
+			s.samples[xi].width += c.width
+			continue
+		}
+		rank -= x.width
+		xi--
+		s.samples[xi] = c
+	}
+	xi--
+	s.samples[xi] = s.samples[0]
+	s.samples = append(s.samples[:0], s.samples[xi:]...)
+}
+
+// query returns the value of the sample whose rank bounds are closest to
+// q·n. The invariant guarantees one lies within the objective's error.
+func (s *Summary) query(q float64) float64 {
+	if len(s.samples) == 0 {
+		return math.NaN()
+	}
+
+	target := q * s.n
+	best, bestErr := 0, math.Inf(1)
+	var rank float64
+	for i, c := range s.samples {
+		rank += c.width
+		if err := max(target-rank, rank+c.delta-target); err < bestErr {
+			best, bestErr = i, err
+		}
+	}
+	return s.samples[best].value
+}
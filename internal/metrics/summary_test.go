@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSummaryQuantilesWithinError(t *testing.T) {
+	counters := NewCounters()
+	s := counters.MustSummary("check_seconds", nil)
+
+	const n = 10000
+	rng := rand.New(rand.NewSource(1))
+	for _, v := range rng.Perm(n) {
+		s.Observe(float64(v + 1))
+	}
+
+	snap := s.Snapshot()
+	if snap.Count != n || snap.Sum != n*(n+1)/2 {
+		t.Fatalf("count, sum = %d, %v", snap.Count, snap.Sum)
+	}
+	for _, q := range snap.Quantiles {
+		// Values are 1..n, so a value is its own rank.
+		epsilon := DefaultObjectives[q.Q]
+		if math.Abs(q.Value-q.Q*n) > epsilon*n+1 {
+			t.Errorf("quantile %v = %v, want %v ± %v", q.Q, q.Value, q.Q*n, epsilon*n)
+		}
+	}
+
+	s.mu.Lock()
+	kept := len(s.samples)
+	s.mu.Unlock()
+	if kept >= n/4 {
+		t.Errorf("summary kept %d samples for %d observations, want far fewer", kept, n)
+	}
+
+	text := counters.PlainText()
+	if !strings.HasPrefix(text, `check_seconds{quantile="0.5"} `) || !strings.Contains(text, "check_seconds_count 10000\n") {
+		t.Fatalf("PlainText() = %q", text)
+	}
+}
+
+func TestSummaryRankErrorOnOrderedStreams(t *testing.T) {
+	streams := map[string]func(n int) []int{
+		"ascending": func(n int) []int {
+			values := make([]int, n)
+			for i := range values {
+				values[i] = i
+			}
+			return values
+		},
+		"descending": func(n int) []int {
+			values := make([]int, n)
+			for i := range values {
+				values[i] = n - 1 - i
+			}
+			return values
+		},
+		"random": func(n int) []int { return rand.New(rand.NewSource(int64(n))).Perm(n) },
+	}
+	for name, stream := range streams {
+		for _, n := range []int{1000, 10000, 25000, 50000} {
+			s := NewCounters().MustSummary("stream_seconds", nil)
+			for _, v := range stream(n) {
+				s.Observe(float64(v))
+			}
+
+			for _, q := range s.Snapshot().Quantiles {
+				// Values are 0..n-1, so value v has rank v+1.
+				rank := (q.Value + 1) / float64(n)
+				if epsilon := DefaultObjectives[q.Q]; math.Abs(rank-q.Q) > epsilon {
+					t.Errorf("%s n=%d: quantile %v has rank %.4f, want within %v", name, n, q.Q, rank, epsilon)
+				}
+			}
+		}
+	}
+}
+
+func TestSummaryEmpty(t *testing.T) {
+	s := NewCounters().MustSummary("idle_seconds", map[float64]float64{0.5: 0.05})
+
+	snap := s.Snapshot()
+	if len(snap.Quantiles) != 1 || !math.IsNaN(snap.Quantiles[0].Value) || snap.Count != 0 {
+		t.Fatalf("empty snapshot = %+v, want NaN median", snap)
+	}
+}
+
+func TestSummaryValidation(t *testing.T) {
+	counters := NewCounters()
+	for _, objectives := range []map[float64]float64{{}, {0: 0.1}, {1: 0.1}, {0.5: 0}, {0.5: 1}} {
+		if _, err := counters.Summary("bad_seconds", objectives); err == nil {
+			t.Errorf("Summary(%v) = nil error, want failure", objectives)
+		}
+	}
+
+	counters.MustSummary("ok_seconds", nil)
+	if _, err := counters.Summary("ok_seconds", map[float64]float64{0.5: 0.05}); err == nil {
+		t.Error("Summary() re-registered with different objectives")
+	}
+}
+
+func TestSummaryConcurrent(t *testing.T) {
+	s := NewCounters().MustSummary("work_seconds", map[float64]float64{0.5: 0.01})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				s.Observe(float64(j))
+				if j%250 == 0 {
+					s.Snapshot()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	snap := s.Snapshot()
+	if snap.Count != 20000 {
+		t.Fatalf("count = %d, want 20000", snap.Count)
+	}
+	if median := snap.Quantiles[0].Value; math.Abs(median-500) > 0.01*1000+1 {
+		t.Fatalf("median = %v, want about 500", median)
+	}
+}
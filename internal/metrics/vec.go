@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSeriesLimit caps how many label combinations a vector tracks
+// before folding new ones into its overflow series.
+const DefaultSeriesLimit = 1000
+
+// OverflowLabelValue is the value of every label on a vector's overflow
+// series.
+const OverflowLabelValue = "_overflow"
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Label is one name/value pair of a series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Counter is one labeled series of a CounterVec.
+type Counter struct {
+	labels []Label
+	value  atomic.Uint64
+}
+
+func (c *Counter) Inc() uint64 {
+	return c.value.Add(1)
+}
+
+func (c *Counter) Add(delta uint64) uint64 {
+	return c.value.Add(delta)
+}
+
+func (c *Counter) Get() uint64 {
+	return c.value.Load()
+}
+
+// Labels returns the series' labels in the vector's label-name order.
+func (c *Counter) Labels() []Label {
+	return append([]Label(nil), c.labels...)
+}
+
+// CounterVec is a family of counters sharing a name and partitioned by a
+// fixed set of label names.
+type CounterVec struct {
+	name       string
+	labelNames []string
+
+	mu       sync.RWMutex
+	limit    int
+	series   map[string]*Counter
+	overflow *Counter
+}
+
+// CounterVec returns the vector called name with the given label names,
+// creating it on first use. It fails if a name is invalid or the vector
+// already exists with different label names.
+func (c *Counters) CounterVec(name string, labelNames ...string) (*CounterVec, error) {
+	if err := validateVec(name, labelNames); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if vec, ok := c.vecs[name]; ok {
+		if !slices.Equal(vec.labelNames, labelNames) {
+			return nil, fmt.Errorf("metric %q already registered with labels %v", name, vec.labelNames)
+		}
+		return vec, nil
+	}
+	if err := c.checkName(name); err != nil {
+		return nil, err
+	}
+
+	vec := &CounterVec{
+		name:       name,
+		labelNames: append([]string(nil), labelNames...),
+		limit:      DefaultSeriesLimit,
+		series:     make(map[string]*Counter),
+	}
+	c.vecs[name] = vec
+	return vec, nil
+}
+
+// MustCounterVec is like CounterVec but panics on error. It suits vectors
+// declared with constant names.
+func (c *Counters) MustCounterVec(name string, labelNames ...string) *CounterVec {
+	vec, err := c.CounterVec(name, labelNames...)
+	if err != nil {
+		panic(err)
+	}
+	return vec
+}
+
+// SetSeriesLimit changes the cardinality limit; n below one means no limit.
+// Existing series are kept.
+func (v *CounterVec) SetSeriesLimit(n int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.limit = n
+}
+
+// WithLabels returns the series for the given name/value pairs, e.g.
+// WithLabels("path", "/hello", "status", "200"). Pairs may come in any
+// order but must name exactly the vector's labels; anything else is a
+// programming error and panics. Once the series limit is reached, new label
+// combinations share the overflow series.
+func (v *CounterVec) WithLabels(pairs ...string) *Counter {
+	values, err := v.labelValues(pairs)
+	if err != nil {
+		panic(err)
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	series, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return series
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if series, ok := v.series[key]; ok {
+		return series
+	}
+	if v.limit > 0 && len(v.series) >= v.limit {
+		if v.overflow == nil {
+			v.overflow = &Counter{labels: v.labelsFor(nil)}
+		}
+		return v.overflow
+	}
+
+	series = &Counter{labels: v.labelsFor(values)}
+	v.series[key] = series
+	return series
+}
+
+// Series returns every series, including the overflow series once used,
+// sorted by label values.
+func (v *CounterVec) Series() []*Counter {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	out := make([]*Counter, 0, len(v.series)+1)
+	for _, series := range v.series {
+		out = append(out, series)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return labelKey(out[i].labels) < labelKey(out[j].labels)
+	})
+	if v.overflow != nil {
+		out = append(out, v.overflow)
+	}
+	return out
+}
+
+func (v *CounterVec) labelValues(pairs []string) ([]string, error) {
+	if len(pairs) != 2*len(v.labelNames) {
+		return nil, fmt.Errorf("metric %q: got %d label pairs, want labels %v", v.name, len(pairs)/2, v.labelNames)
+	}
+
+	values := make([]string, len(v.labelNames))
+	seen := make([]bool, len(v.labelNames))
+	for i := 0; i < len(pairs); i += 2 {
+		idx := slices.Index(v.labelNames, pairs[i])
+		if idx < 0 || seen[idx] {
+			return nil, fmt.Errorf("metric %q: unexpected or repeated label %q, want labels %v", v.name, pairs[i], v.labelNames)
+		}
+		seen[idx] = true
+		values[idx] = pairs[i+1]
+	}
+	return values, nil
+}
+
+// labelsFor pairs values with the label names; nil values gives the
+// overflow labels.
+func (v *CounterVec) labelsFor(values []string) []Label {
+	labels := make([]Label, len(v.labelNames))
+	for i, name := range v.labelNames {
+		value := OverflowLabelValue
+		if values != nil {
+			value = values[i]
+		}
+		labels[i] = Label{Name: name, Value: value}
+	}
+	return labels
+}
+
+func validateVec(name string, labelNames []string) error {
+	if !metricNameRE.MatchString(name) {
+		return fmt.Errorf("invalid metric name %q", name)
+	}
+	if len(labelNames) == 0 {
+		return errors.New("metric vector needs at least one label")
+	}
+	for i, label := range labelNames {
+		if !labelNameRE.MatchString(label) || strings.HasPrefix(label, "__") {
+			return fmt.Errorf("metric %q: invalid label name %q", name, label)
+		}
+		if slices.Contains(labelNames[:i], label) {
+			return fmt.Errorf("metric %q: duplicate label name %q", name, label)
+		}
+	}
+	return nil
+}
+
+// formatSeries renders name{a="x",b="y"} with label values escaped.
+func formatSeries(name string, labels []Label) string {
+	if len(labels) == 0 {
+		return name
+	}
+	return name + "{" + labelKey(labels) + "}"
+}
+
+func labelKey(labels []Label) string {
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = label.Name + `="` + escapeLabelValue(label.Value) + `"`
+	}
+	return strings.Join(parts, ",")
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
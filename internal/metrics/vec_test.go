@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCounterVecWithLabels(t *testing.T) {
+	counters := NewCounters()
+	vec := counters.MustCounterVec("http_responses_total", "path", "status")
+
+	vec.WithLabels("path", "/hello", "status", "200").Inc()
+	vec.WithLabels("status", "200", "path", "/hello").Add(2)
+	vec.WithLabels("path", "/health", "status", "200").Inc()
+
+	if got := vec.WithLabels("path", "/hello", "status", "200").Get(); got != 3 {
+		t.Fatalf("hello series = %d, want 3 regardless of pair order", got)
+	}
+
+	text := counters.PlainText()
+	want := "http_responses_total{path=\"/health\",status=\"200\"} 1\n" +
+		"http_responses_total{path=\"/hello\",status=\"200\"} 3\n"
+	if text != want {
+		t.Fatalf("PlainText() =\n%s\nwant:\n%s", text, want)
+	}
+}
+
+func TestCounterVecGetOrCreate(t *testing.T) {
+	counters := NewCounters()
+	first := counters.MustCounterVec("jobs_total", "queue")
+	second, err := counters.CounterVec("jobs_total", "queue")
+	if err != nil || first != second {
+		t.Fatalf("CounterVec() = %p, %v; want the existing vector", second, err)
+	}
+	if _, err := counters.CounterVec("jobs_total", "state"); err == nil {
+		t.Fatal("expected error re-registering with different labels")
+	}
+}
+
+func TestCounterVecValidation(t *testing.T) {
+	counters := NewCounters()
+	cases := []struct {
+		name   string
+		labels []string
+	}{
+		{"bad-name", []string{"a"}},
+		{"9starts_with_digit", []string{"a"}},
+		{"ok_total", nil},
+		{"ok_total", []string{"bad-label"}},
+		{"ok_total", []string{"__reserved"}},
+		{"ok_total", []string{"a", "a"}},
+	}
+	for _, tc := range cases {
+		if _, err := counters.CounterVec(tc.name, tc.labels...); err == nil {
+			t.Errorf("CounterVec(%q, %v) = nil error, want validation failure", tc.name, tc.labels)
+		}
+	}
+}
+
+func TestCounterVecWithLabelsPanicsOnMismatch(t *testing.T) {
+	vec := NewCounters().MustCounterVec("jobs_total", "queue", "state")
+	for _, pairs := range [][]string{
+		{"queue", "a"},
+		{"queue", "a", "other", "b"},
+		{"queue", "a", "queue", "b"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("WithLabels(%v) did not panic", pairs)
+				}
+			}()
+			vec.WithLabels(pairs...)
+		}()
+	}
+}
+
+func TestCounterVecSeriesLimit(t *testing.T) {
+	counters := NewCounters()
+	vec := counters.MustCounterVec("requests_total", "path")
+	vec.SetSeriesLimit(2)
+
+	vec.WithLabels("path", "/a").Inc()
+	vec.WithLabels("path", "/b").Inc()
+	vec.WithLabels("path", "/c").Inc()
+	vec.WithLabels("path", "/d").Inc()
+	vec.WithLabels("path", "/a").Inc()
+
+	series := vec.Series()
+	if len(series) != 3 {
+		t.Fatalf("series = %d, want 2 plus overflow", len(series))
+	}
+	overflow := series[2]
+	if overflow.Labels()[0].Value != OverflowLabelValue || overflow.Get() != 2 {
+		t.Fatalf("overflow = %v %d, want 2 folded requests", overflow.Labels(), overflow.Get())
+	}
+	if vec.WithLabels("path", "/a").Get() != 2 {
+		t.Fatal("existing series should keep counting after the limit is hit")
+	}
+}
+
+func TestCounterVecEscapesLabelValues(t *testing.T) {
+	counters := NewCounters()
+	counters.MustCounterVec("odd_total", "value").WithLabels("value", "a\"b\\c\nd").Inc()
+
+	if text := counters.PlainText(); !strings.Contains(text, `odd_total{value="a\"b\\c\nd"} 1`) {
+		t.Fatalf("PlainText() = %q, want escaped label value", text)
+	}
+}
+
+func TestCounterVecConcurrent(t *testing.T) {
+	vec := NewCounters().MustCounterVec("shared_total", "worker")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vec.WithLabels("worker", "w").Inc()
+		}()
+	}
+	wg.Wait()
+
+	if got := vec.WithLabels("worker", "w").Get(); got != 50 {
+		t.Fatalf("Get() = %d, want 50", got)
+	}
+}
@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StandardSpans are the windows a Counters meter tracks: 1, 5 and 15
+// minutes.
+var StandardSpans = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// meterBuckets is how many buckets each meter window has, so a 1m window
+// has 1s resolution.
+const meterBuckets = 60
+
+// Window sums observations over a sliding span of time, kept in a ring of
+// buckets each covering resolution. It answers for the current bucket plus
+// the full buckets before it, so the span it covers moves in resolution
+// steps.
+type Window struct {
+	span       time.Duration
+	resolution time.Duration
+	now        func() time.Time
+	created    time.Time
+
+	mu      sync.Mutex
+	buckets []windowBucket
+}
+
+type windowBucket struct {
+	slot  int64 // time since the epoch in resolution units
+	sum   float64
+	count uint64
+}
+
+// NewWindow returns a window over span with buckets of resolution. A
+// resolution of zero or less, or above span, gives 60 buckets.
+func NewWindow(span, resolution time.Duration) *Window {
+	if resolution <= 0 || resolution > span {
+		resolution = max(span/meterBuckets, time.Nanosecond)
+	}
+	n := int((span + resolution - 1) / resolution)
+	return newWindow(span, resolution, n, time.Now)
+}
+
+func newWindow(span, resolution time.Duration, n int, now func() time.Time) *Window {
+	return &Window{
+		span:       span,
+		resolution: resolution,
+		now:        now,
+		created:    now(),
+		buckets:    make([]windowBucket, n),
+	}
+}
+
+// Add records one observation of v.
+func (w *Window) Add(v float64) {
+	slot := w.slot(w.now())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b := &w.buckets[w.index(slot)]
+	if b.slot != slot {
+		*b = windowBucket{slot: slot}
+	}
+	b.sum += v
+	b.count++
+}
+
+// Sum returns the sum and number of observations in the window.
+func (w *Window) Sum() (sum float64, count uint64) {
+	slot := w.slot(w.now())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	oldest := slot - int64(len(w.buckets)) + 1
+	for _, b := range w.buckets {
+		if b.slot >= oldest && b.slot <= slot {
+			sum += b.sum
+			count += b.count
+		}
+	}
+	return sum, count
+}
+
+// Rate returns the sum per second over the window. While the window is
+// younger than its span, the rate is over its age instead, so a new window
+// is not diluted by time before it existed.
+func (w *Window) Rate() float64 {
+	sum, _ := w.Sum()
+	elapsed := min(w.span, max(w.now().Sub(w.created), w.resolution))
+	return sum / elapsed.Seconds()
+}
+
+// Mean returns the moving average of the observations in the window, or
+// NaN when there are none.
+func (w *Window) Mean() float64 {
+	sum, count := w.Sum()
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+func (w *Window) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(w.resolution)
+}
+
+func (w *Window) index(slot int64) int {
+	n := int64(len(w.buckets))
+	return int(((slot % n) + n) % n)
+}
+
+// Meter tracks observations over several windows at once, such as the
+// 1m/5m/15m rates of a counter.
+type Meter struct {
+	spans   []time.Duration
+	windows []*Window
+}
+
+// NewMeter returns a meter with one 60-bucket window per span; no spans
+// means StandardSpans.
+func NewMeter(spans ...time.Duration) *Meter {
+	return newMeter(time.Now, spans...)
+}
+
+func newMeter(now func() time.Time, spans ...time.Duration) *Meter {
+	if len(spans) == 0 {
+		spans = StandardSpans
+	}
+	m := &Meter{spans: append([]time.Duration(nil), spans...)}
+	for _, span := range spans {
+		m.windows = append(m.windows, newWindow(span, max(span/meterBuckets, time.Nanosecond), meterBuckets, now))
+	}
+	return m
+}
+
+// Mark records one observation of v in every window; for a counter, v is
+// the increment.
+func (m *Meter) Mark(v float64) {
+	for _, w := range m.windows {
+		w.Add(v)
+	}
+}
+
+// Rates returns the per-second rate of each window, keyed by its span
+// ("1m", "5m", "15m").
+func (m *Meter) Rates() map[string]float64 {
+	rates := make(map[string]float64, len(m.windows))
+	for i, w := range m.windows {
+		rates[formatSpan(m.spans[i])] = w.Rate()
+	}
+	return rates
+}
+
+// Averages returns the moving average of each window, keyed like Rates;
+// windows without observations are left out.
+func (m *Meter) Averages() map[string]float64 {
+	averages := make(map[string]float64, len(m.windows))
+	for i, w := range m.windows {
+		if mean := w.Mean(); !math.IsNaN(mean) {
+			averages[formatSpan(m.spans[i])] = mean
+		}
+	}
+	return averages
+}
+
+// formatSpan renders a duration without zero trailing units: 1m, 1h,
+// 1m30s.
+func formatSpan(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
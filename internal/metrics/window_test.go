@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for windows.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestWindowSlidesOutOldBuckets(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	w := newWindow(10*time.Second, time.Second, 10, clock.now)
+
+	for i := 0; i < 10; i++ {
+		w.Add(1)
+		clock.advance(time.Second)
+	}
+	// The first observation's bucket has just left the window.
+	if sum, count := w.Sum(); sum != 9 || count != 9 {
+		t.Fatalf("Sum() = %v, %d; want 9, 9", sum, count)
+	}
+
+	clock.advance(5 * time.Second)
+	if sum, _ := w.Sum(); sum != 4 {
+		t.Fatalf("Sum() after 5s = %v, want 4", sum)
+	}
+	clock.advance(time.Hour)
+	if sum, count := w.Sum(); sum != 0 || count != 0 {
+		t.Fatalf("Sum() after an hour = %v, %d; want empty", sum, count)
+	}
+}
+
+func TestWindowRateAndMean(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	w := newWindow(time.Minute, time.Second, 60, clock.now)
+
+	if !math.IsNaN(w.Mean()) {
+		t.Fatal("Mean() of an empty window should be NaN")
+	}
+
+	for i := 0; i < 20; i++ {
+		w.Add(float64(i % 2 * 4)) // alternating 0 and 4
+		clock.advance(500 * time.Millisecond)
+	}
+	// 10s old: the rate is over its age, not the full minute.
+	if got := w.Rate(); math.Abs(got-4) > 1e-9 {
+		t.Fatalf("Rate() = %v, want 40/10s = 4", got)
+	}
+	if got := w.Mean(); got != 2 {
+		t.Fatalf("Mean() = %v, want 2", got)
+	}
+
+	clock.advance(49 * time.Second)
+	if got := w.Rate(); math.Abs(got-40.0/59) > 1e-9 {
+		t.Fatalf("Rate() = %v, want 40/59s", got)
+	}
+	clock.advance(time.Second)
+	if got := w.Rate(); math.Abs(got-36.0/60) > 1e-9 {
+		t.Fatalf("Rate() = %v, want 36/60s once the first second slid out", got)
+	}
+}
+
+func TestNewWindowResolution(t *testing.T) {
+	if w := NewWindow(time.Minute, 0); len(w.buckets) != 60 || w.resolution != time.Second {
+		t.Fatalf("default resolution: %d buckets of %s", len(w.buckets), w.resolution)
+	}
+	if w := NewWindow(time.Minute, 7*time.Second); len(w.buckets) != 9 {
+		t.Fatalf("7s resolution over 1m: %d buckets, want 9", len(w.buckets))
+	}
+}
+
+func TestMeterRatesPerSpan(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	m := newMeter(clock.now)
+
+	for i := 0; i < 600; i++ {
+		m.Mark(1)
+		clock.advance(time.Second)
+	}
+	// 600 marks over 10 minutes at one per second.
+	rates := m.Rates()
+	for span, want := range map[string]float64{"1m": 1, "5m": 1, "15m": 600.0 / 600} {
+		if math.Abs(rates[span]-want) > 0.05 {
+			t.Errorf("rate %s = %v, want about %v", span, rates[span], want)
+		}
+	}
+
+	clock.advance(2 * time.Minute)
+	rates = m.Rates()
+	if rates["1m"] != 0 || math.Abs(rates["5m"]-180.0/300) > 0.05 || math.Abs(rates["15m"]-600.0/720) > 0.05 {
+		t.Errorf("rates after 2 idle minutes = %v", rates)
+	}
+	if avg := m.Averages(); len(avg) != 2 || avg["5m"] != 1 {
+		t.Errorf("Averages() = %v, want 5m and 15m only", avg)
+	}
+}
+
+func TestCountersMeterFeedsFromAdd(t *testing.T) {
+	counters := NewCounters()
+	counters.Inc("http_requests_total")
+	meter := counters.Meter("HTTP requests total")
+	counters.Add("http_requests_total", 5)
+
+	if sum, _ := meter.windows[0].Sum(); sum != 5 {
+		t.Fatalf("meter saw %v, want only the 5 after it was created", sum)
+	}
+	rates := counters.Rates()
+	if _, ok := rates["http_requests_total"]["15m"]; !ok || len(rates) != 1 {
+		t.Fatalf("Rates() = %v", rates)
+	}
+}
+
+func TestFormatSpan(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		time.Minute:               "1m",
+		15 * time.Minute:          "15m",
+		time.Hour:                 "1h",
+		90 * time.Second:          "1m30s",
+		10 * time.Second:          "10s",
+		time.Hour + 5*time.Minute: "1h5m",
+	} {
+		if got := formatSpan(d); got != want {
+			t.Errorf("formatSpan(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -1,59 +1,138 @@
 package middleware
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/itprodirect/go-hello-world/internal/metrics"
 )
 
-// Logger logs method, path, status, and duration.
-func Logger(logger *log.Logger, next http.Handler) http.Handler {
+// AccessLog logs one entry per request with its method, path, status,
+// response bytes, duration, remote address, user agent and request id,
+// plus trace and span ids under TraceContext. The request id comes from
+// RequestID, or the X-Request-ID header when that is not installed; both
+// must wrap AccessLog to be seen. Server errors are logged at error level,
+// everything else at info.
+func AccessLog(logger *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(sw, r)
-		logger.Printf("%s %s %d %s", r.Method, r.URL.Path, sw.status, time.Since(start).Round(time.Microsecond))
+
+		level := slog.LevelInfo
+		if sw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		requestID := RequestIDFrom(r.Context())
+		if requestID == "" {
+			requestID = r.Header.Get("X-Request-ID")
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", sw.status),
+			slog.Int64("bytes", sw.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+			slog.String("request_id", requestID),
+		}
+		if trace, ok := TraceFrom(r.Context()); ok {
+			attrs = append(attrs, slog.String("trace_id", trace.TraceID), slog.String("span_id", trace.SpanID))
+		}
+		logger.LogAttrs(r.Context(), level, "request", attrs...)
 	})
 }
 
 type statusWriter struct {
 	http.ResponseWriter
-	status int
+	status      int
+	bytes       int64
+	wroteHeader bool
 }
 
 func (w *statusWriter) WriteHeader(code int) {
 	w.status = code
+	w.wroteHeader = true
 	w.ResponseWriter.WriteHeader(code)
 }
 
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
 // Recover catches panics and converts them to 500 responses.
-func Recover(logger *log.Logger, next http.Handler) http.Handler {
+func Recover(logger *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				logger.Printf("PANIC: %v\n%s", err, debug.Stack())
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				logger.ErrorContext(r.Context(), "panic",
+					"error", fmt.Sprint(err),
+					"method", r.Method,
+					"path", r.URL.Path,
+					"stack", string(debug.Stack()),
+				)
+				Error(w, r, "internal server error", http.StatusInternalServerError)
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// RequestCounter increments global and per-path counters for each request.
+// RequestCounter counts every request in http_requests_total and each
+// response in http_responses_total, labeled by method, path and status. A
+// handler that panics before writing a status is counted as a 500 and the
+// panic is passed on for Recover.
 func RequestCounter(counters *metrics.Counters, next http.Handler) http.Handler {
+	responses := counters.MustCounterVec("http_responses_total", "method", "path", "status")
+	counters.SetHelp("http_requests_total", "HTTP requests received.")
+	counters.SetHelp("http_responses_total", "HTTP responses by method, path and status code.")
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		counters.Inc("http_requests_total")
 
-		path := strings.Trim(r.URL.Path, "/")
-		if path == "" {
-			path = "root"
-		}
-		counters.Inc("path_" + path + "_requests")
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			err := recover()
+			status := sw.status
+			if err != nil && !sw.wroteHeader {
+				status = http.StatusInternalServerError
+			}
+			responses.WithLabels(
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", strconv.Itoa(status),
+			).Inc()
+			if err != nil {
+				panic(err)
+			}
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// Latency tracks requests in flight in http_requests_in_flight and records
+// each request's duration in the http_request_duration_seconds histogram.
+func Latency(counters *metrics.Counters, next http.Handler) http.Handler {
+	inFlight := counters.MustGauge("http_requests_in_flight")
+	duration := counters.MustHistogram("http_request_duration_seconds", metrics.DefaultBuckets)
+	counters.SetHelp("http_requests_in_flight", "HTTP requests being served.")
+	counters.SetHelp("http_request_duration_seconds", "HTTP request latency in seconds.")
 
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		defer func() { duration.Observe(time.Since(start).Seconds()) }()
 		next.ServeHTTP(w, r)
 	})
 }
@@ -67,7 +146,7 @@ func AllowMethods(methods []string, next http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !allowed[r.Method] {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			Error(w, r, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 		next.ServeHTTP(w, r)
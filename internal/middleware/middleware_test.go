@@ -2,7 +2,8 @@ package middleware
 
 import (
 	"bytes"
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -24,27 +25,60 @@ func panicHandler() http.Handler {
 	})
 }
 
-func TestLogger(t *testing.T) {
+func TestAccessLog(t *testing.T) {
 	var buf bytes.Buffer
-	logger := log.New(&buf, "", 0)
-	handler := Logger(logger, okHandler())
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := AccessLog(logger, okHandler())
 
 	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.RemoteAddr = "192.0.2.7:5555"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("X-Request-ID", "req-123")
 	rec := httptest.NewRecorder()
 	handler.ServeHTTP(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("status = %d, want 200", rec.Code)
 	}
-	logLine := buf.String()
-	if !strings.Contains(logLine, "GET") || !strings.Contains(logLine, "/hello") {
-		t.Errorf("log line missing expected fields: %q", logLine)
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log entry is not JSON: %v: %q", err, buf.String())
+	}
+	want := map[string]any{
+		"level":       "INFO",
+		"msg":         "request",
+		"method":      "GET",
+		"path":        "/hello",
+		"status":      float64(200),
+		"bytes":       float64(2),
+		"remote_addr": "192.0.2.7:5555",
+		"user_agent":  "curl/8.0",
+		"request_id":  "req-123",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	if _, ok := entry["duration"].(float64); !ok {
+		t.Errorf("duration = %v, want a number", entry["duration"])
+	}
+}
+
+func TestAccessLogServerErrorLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	handler := AccessLog(logger, Recover(logger, panicHandler()))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(buf.String(), "level=ERROR msg=request") || !strings.Contains(buf.String(), "status=500") {
+		t.Errorf("expected an error-level access entry, got: %q", buf.String())
 	}
 }
 
 func TestRecover(t *testing.T) {
 	var buf bytes.Buffer
-	logger := log.New(&buf, "", 0)
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
 	handler := Recover(logger, panicHandler())
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -54,8 +88,8 @@ func TestRecover(t *testing.T) {
 	if rec.Code != http.StatusInternalServerError {
 		t.Errorf("status = %d, want 500", rec.Code)
 	}
-	if !strings.Contains(buf.String(), "PANIC") {
-		t.Errorf("expected PANIC log, got: %q", buf.String())
+	if !strings.Contains(buf.String(), `msg=panic error="test panic"`) || !strings.Contains(buf.String(), "stack=") {
+		t.Errorf("expected panic entry with stack, got: %q", buf.String())
 	}
 }
 
@@ -70,8 +104,61 @@ func TestRequestCounter(t *testing.T) {
 	if got := counters.Get("http_requests_total"); got != 2 {
 		t.Errorf("http_requests_total = %d, want 2", got)
 	}
-	if got := counters.Get("path_hello_requests"); got != 2 {
-		t.Errorf("path_hello_requests = %d, want 2", got)
+	responses := counters.MustCounterVec("http_responses_total", "method", "path", "status")
+	if got := responses.WithLabels("method", "GET", "path", "/hello", "status", "200").Get(); got != 2 {
+		t.Errorf(`http_responses_total{path="/hello"} = %d, want 2`, got)
+	}
+}
+
+func TestRequestCounterLabelsStatus(t *testing.T) {
+	counters := metrics.NewCounters()
+	handler := RequestCounter(counters, AllowMethods([]string{http.MethodGet}, okHandler()))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/hello", nil))
+
+	got := counters.Snapshot()[`http_responses_total{method="POST",path="/hello",status="405"}`]
+	if got != 1 {
+		t.Fatalf("snapshot = %v, want one 405 response", counters.Snapshot())
+	}
+}
+
+func TestRequestCounterCountsPanicsAs500(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	counters := metrics.NewCounters()
+	outside := RequestCounter(counters, Recover(logger, panicHandler()))
+	inside := Recover(logger, RequestCounter(counters, panicHandler()))
+
+	for _, handler := range []http.Handler{outside, inside} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("status = %d, want 500", rec.Code)
+		}
+	}
+
+	got := counters.Snapshot()[`http_responses_total{method="GET",path="/boom",status="500"}`]
+	if got != 2 {
+		t.Fatalf("snapshot = %v, want two 500 responses", counters.Snapshot())
+	}
+}
+
+func TestLatency(t *testing.T) {
+	counters := metrics.NewCounters()
+	inFlight := counters.MustGauge("http_requests_in_flight")
+	var during float64
+	handler := Latency(counters, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		during = inFlight.Get()
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	if during != 1 || inFlight.Get() != 0 {
+		t.Errorf("in flight = %v during and %v after, want 1 and 0", during, inFlight.Get())
+	}
+	snap := counters.MustHistogram("http_request_duration_seconds", metrics.DefaultBuckets).Snapshot()
+	if snap.Count != 2 {
+		t.Errorf("duration count = %d, want 2", snap.Count)
 	}
 }
 
@@ -94,11 +181,11 @@ func TestAllowMethods(t *testing.T) {
 func TestChain(t *testing.T) {
 	counters := metrics.NewCounters()
 	var buf bytes.Buffer
-	logger := log.New(&buf, "", 0)
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
 
 	handler := Chain(
 		okHandler(),
-		func(h http.Handler) http.Handler { return Logger(logger, h) },
+		func(h http.Handler) http.Handler { return AccessLog(logger, h) },
 		func(h http.Handler) http.Handler { return Recover(logger, h) },
 		func(h http.Handler) http.Handler { return RequestCounter(counters, h) },
 	)
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceKey
+)
+
+// maxRequestIDLength bounds accepted X-Request-ID values.
+const maxRequestIDLength = 128
+
+// RequestID gives each request an id: the incoming X-Request-ID when it is
+// well formed (1-128 letters, digits and -_.:/+=), otherwise a new random
+// one. The id is stored in the request context and set as X-Request-ID on
+// the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = randomHex(16)
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// RequestIDFrom returns the id RequestID stored in ctx, or "".
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-_.:/+=", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Trace is a request's W3C trace context.
+type Trace struct {
+	// TraceID is the 32-hex-digit id shared by every span of the trace.
+	TraceID string
+	// SpanID is this server's 16-hex-digit span for the request.
+	SpanID string
+	// ParentID is the caller's span, or "" when the trace started here.
+	ParentID string
+	// Flags are the trace flags as two hex digits; 01 means sampled.
+	Flags string
+	// State is the vendor tracestate list, passed through unchanged.
+	State string
+}
+
+// Traceparent renders the context for a traceparent header, naming this
+// server's span as the parent.
+func (t Trace) Traceparent() string {
+	return "00-" + t.TraceID + "-" + t.SpanID + "-" + t.Flags
+}
+
+// TraceContext continues the caller's W3C trace context or starts a new
+// one. A valid traceparent header keeps its trace id and flags and gets a
+// new span id for this server; tracestate is kept only alongside a valid
+// traceparent. The Trace is stored in the request context and both headers
+// are set on the response.
+func TraceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace, ok := parseTraceparent(r.Header.Get("traceparent"))
+		if ok {
+			trace.State = parseTracestate(r.Header.Values("tracestate"))
+		} else {
+			trace = Trace{TraceID: randomHex(16), Flags: "00"}
+		}
+		trace.SpanID = randomHex(8)
+
+		w.Header().Set("traceparent", trace.Traceparent())
+		if trace.State != "" {
+			w.Header().Set("tracestate", trace.State)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), traceKey, trace)))
+	})
+}
+
+// TraceFrom returns the Trace TraceContext stored in ctx.
+func TraceFrom(ctx context.Context) (Trace, bool) {
+	trace, ok := ctx.Value(traceKey).(Trace)
+	return trace, ok
+}
+
+// parseTraceparent parses version-trace_id-parent_id-flags. Versions above
+// 00 may append fields, which are ignored; version ff and all-zero ids are
+// invalid.
+func parseTraceparent(header string) (Trace, bool) {
+	header = strings.TrimSpace(header)
+	if len(header) < 55 {
+		return Trace{}, false
+	}
+	version := header[:2]
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(header) != 55) ||
+		(len(header) > 55 && header[55] != '-') {
+		return Trace{}, false
+	}
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return Trace{}, false
+	}
+
+	traceID, parentID, flags := header[3:35], header[36:52], header[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return Trace{}, false
+	}
+	return Trace{TraceID: traceID, ParentID: parentID, Flags: flags}, true
+}
+
+// Limits on tracestate from the W3C spec.
+const (
+	maxTracestateMembers = 32
+	maxTracestateLength  = 512
+)
+
+// parseTracestate joins tracestate headers into one list, dropping empty
+// members. A list over the spec's limits is dropped entirely.
+func parseTracestate(headers []string) string {
+	var members []string
+	for _, header := range headers {
+		for _, member := range strings.Split(header, ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				if !strings.Contains(member, "=") {
+					return ""
+				}
+				members = append(members, member)
+			}
+		}
+	}
+
+	state := strings.Join(members, ",")
+	if len(members) > maxTracestateMembers || len(state) > maxTracestateLength {
+		return ""
+	}
+	return state
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !(s[i] >= '0' && s[i] <= '9' || s[i] >= 'a' && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Error replies like http.Error, appending the request and trace ids so a
+// reported error can be matched to the server's logs.
+func Error(w http.ResponseWriter, r *http.Request, message string, code int) {
+	var ids []string
+	if id := RequestIDFrom(r.Context()); id != "" {
+		ids = append(ids, "request_id="+id)
+	}
+	if trace, ok := TraceFrom(r.Context()); ok {
+		ids = append(ids, "trace_id="+trace.TraceID)
+	}
+	if len(ids) > 0 {
+		message += " (" + strings.Join(ids, ", ") + ")"
+	}
+	http.Error(w, message, code)
+}
+
+// ContextHandler wraps h so records logged with a request context carry
+// its request_id, trace_id and span_id, unless the record already has
+// them.
+func ContextHandler(h slog.Handler) slog.Handler {
+	return contextHandler{h}
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		return h.Handler.Handle(ctx, record)
+	}
+
+	present := map[string]bool{}
+	record.Attrs(func(a slog.Attr) bool {
+		present[a.Key] = true
+		return true
+	})
+	add := func(key, value string) {
+		if value != "" && !present[key] {
+			record.AddAttrs(slog.String(key, value))
+		}
+	}
+
+	add("request_id", RequestIDFrom(ctx))
+	if trace, ok := TraceFrom(ctx); ok {
+		add("trace_id", trace.TraceID)
+		add("span_id", trace.SpanID)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
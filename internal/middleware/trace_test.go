@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	generatedIDRE = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	traceparentRE = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+)
+
+// captureContext runs handler and returns the context its inner handler saw
+// along with the response.
+func captureContext(mw func(http.Handler) http.Handler, req *http.Request) (context.Context, *httptest.ResponseRecorder) {
+	var ctx context.Context
+	rec := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ctx = r.Context() })).ServeHTTP(rec, req)
+	return ctx, rec
+}
+
+func TestRequestIDAcceptsOrGenerates(t *testing.T) {
+	tests := []struct {
+		header   string
+		keep     bool
+		generate bool
+	}{
+		{header: "gateway-7f3a.42", keep: true},
+		{header: "", generate: true},
+		{header: "has space", generate: true},
+		{header: "new\nline", generate: true},
+		{header: strings.Repeat("a", maxRequestIDLength+1), generate: true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header["X-Request-Id"] = []string{tt.header}
+		}
+		ctx, rec := captureContext(RequestID, req)
+
+		id := RequestIDFrom(ctx)
+		if tt.keep && id != tt.header {
+			t.Errorf("header %q: id = %q, want it kept", tt.header, id)
+		}
+		if tt.generate && !generatedIDRE.MatchString(id) {
+			t.Errorf("header %q: id = %q, want a generated id", tt.header, id)
+		}
+		if got := rec.Header().Get("X-Request-ID"); got != id {
+			t.Errorf("response X-Request-ID = %q, want %q", got, id)
+		}
+	}
+
+	if RequestIDFrom(context.Background()) != "" {
+		t.Error("RequestIDFrom() without middleware should be empty")
+	}
+}
+
+func TestTraceContextContinuesValidTrace(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Add("tracestate", "vendor=abc, ")
+	req.Header.Add("tracestate", "other=1")
+
+	ctx, rec := captureContext(TraceContext, req)
+	trace, ok := TraceFrom(ctx)
+	if !ok {
+		t.Fatal("no trace in context")
+	}
+	if trace.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || trace.ParentID != "00f067aa0ba902b7" || trace.Flags != "01" {
+		t.Fatalf("trace = %+v", trace)
+	}
+	if trace.SpanID == trace.ParentID || len(trace.SpanID) != 16 {
+		t.Fatalf("span id = %q, want a new span", trace.SpanID)
+	}
+	if trace.State != "vendor=abc,other=1" {
+		t.Fatalf("tracestate = %q", trace.State)
+	}
+
+	if got := rec.Header().Get("traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-"+trace.SpanID+"-01" {
+		t.Fatalf("response traceparent = %q", got)
+	}
+	if got := rec.Header().Get("tracestate"); got != trace.State {
+		t.Fatalf("response tracestate = %q", got)
+	}
+}
+
+func TestTraceContextRejectsInvalidTraceparent(t *testing.T) {
+	invalid := []string{
+		"",
+		"garbage",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",  // version 00 has no extra fields
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",        // forbidden version
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",        // zero trace id
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",        // zero parent id
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",        // upper case
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",        // bad separator
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01garbage", // extra fields need a dash
+	}
+	for _, header := range invalid {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("traceparent", header)
+		req.Header.Set("tracestate", "vendor=abc")
+
+		ctx, rec := captureContext(TraceContext, req)
+		trace, _ := TraceFrom(ctx)
+		if trace.ParentID != "" || trace.TraceID == "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("traceparent %q: continued trace %+v, want a new one", header, trace)
+		}
+		if trace.State != "" || rec.Header().Get("tracestate") != "" {
+			t.Errorf("traceparent %q: tracestate kept without a valid parent", header)
+		}
+		if !traceparentRE.MatchString(rec.Header().Get("traceparent")) {
+			t.Errorf("traceparent %q: response traceparent = %q", header, rec.Header().Get("traceparent"))
+		}
+	}
+}
+
+func TestTraceContextAcceptsFutureVersion(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-holds")
+
+	ctx, rec := captureContext(TraceContext, req)
+	trace, _ := TraceFrom(ctx)
+	if trace.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace = %+v, want the caller's trace id", trace)
+	}
+	if !strings.HasPrefix(rec.Header().Get("traceparent"), "00-") {
+		t.Fatalf("response traceparent = %q, want version 00", rec.Header().Get("traceparent"))
+	}
+}
+
+func TestParseTracestateLimits(t *testing.T) {
+	members := make([]string, maxTracestateMembers+1)
+	for i := range members {
+		members[i] = "k=v"
+	}
+	if got := parseTracestate([]string{strings.Join(members, ",")}); got != "" {
+		t.Errorf("over-long list kept: %q", got)
+	}
+	if got := parseTracestate([]string{"novalue"}); got != "" {
+		t.Errorf("malformed member kept: %q", got)
+	}
+}
+
+func TestErrorIncludesIDs(t *testing.T) {
+	handler := Chain(
+		AllowMethods([]string{http.MethodGet}, okHandler()),
+		RequestID,
+		TraceContext,
+	)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Request-ID", "req-9")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	traceID := strings.Split(rec.Header().Get("traceparent"), "-")[1]
+	if rec.Code != http.StatusMethodNotAllowed || body != "method not allowed (request_id=req-9, trace_id="+traceID+")\n" {
+		t.Fatalf("status=%d body=%q", rec.Code, body)
+	}
+}
+
+func TestContextHandlerAddsIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(ContextHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	var traceID string
+	handler := Chain(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace, _ := TraceFrom(r.Context())
+			traceID = trace.TraceID
+			logger.InfoContext(r.Context(), "inside")
+			logger.InfoContext(r.Context(), "explicit", "request_id", "override")
+		}),
+		RequestID,
+		TraceContext,
+	)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	logger.Info("outside")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var entries []map[string]any
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("bad JSON %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if e := entries[0]; e["request_id"] != "req-1" || e["trace_id"] != traceID || e["span_id"] == nil || e["component"] != "test" {
+		t.Errorf("inside entry = %v", e)
+	}
+	if e := entries[1]; e["request_id"] != "override" || strings.Count(lines[1], "request_id") != 1 {
+		t.Errorf("explicit entry = %s, want its own request_id only", lines[1])
+	}
+	if e := entries[2]; e["request_id"] != nil || e["trace_id"] != nil {
+		t.Errorf("outside entry = %v, want no ids", e)
+	}
+}
+
+func TestAccessLogIncludesTraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := Chain(okHandler(), RequestID, TraceContext, func(h http.Handler) http.Handler { return AccessLog(logger, h) })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(rec.Header().Get("traceparent"), "-")
+	if entry["request_id"] != rec.Header().Get("X-Request-ID") || entry["trace_id"] != parts[1] || entry["span_id"] != parts[2] {
+		t.Fatalf("access entry = %v, response headers = %v", entry, rec.Header())
+	}
+}
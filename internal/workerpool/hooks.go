@@ -0,0 +1,68 @@
+package workerpool
+
+import "time"
+
+// TaskInfo describes a task to Hooks.
+type TaskInfo[In any] struct {
+	Input In
+	// Worker identifies the worker running the task; it is -1 in OnEnqueue.
+	// Batch runs number their workers from 0; a started pool numbers its
+	// workers in spawn order.
+	Worker int
+	// Enqueued is when the pool took the task: when a batch run was called,
+	// when a stream worker received it, or when Submit queued it.
+	Enqueued time.Time
+	// Started is when the task cleared the pool's limits and began running.
+	Started time.Time
+	// Finished and Err are set in OnFinish. Err is the task's error or a
+	// *PanicError.
+	Finished time.Time
+	Err      error
+}
+
+// Wait is how long the task waited for a worker and the pool's limits.
+func (t TaskInfo[In]) Wait() time.Duration {
+	if t.Started.IsZero() {
+		return 0
+	}
+	return t.Started.Sub(t.Enqueued)
+}
+
+// Run is how long the task ran.
+func (t TaskInfo[In]) Run() time.Duration {
+	if t.Finished.IsZero() {
+		return 0
+	}
+	return t.Finished.Sub(t.Started)
+}
+
+// Hooks are called as tasks move through the pool. They run on the pool's
+// goroutines, concurrently, so they must be safe for concurrent use and
+// quick. Nil hooks are skipped. A submitted task's OnEnqueue can overlap
+// its OnStart; otherwise a task's hooks run in order.
+type Hooks[In any] struct {
+	OnEnqueue func(TaskInfo[In])
+	OnStart   func(TaskInfo[In])
+	OnFinish  func(TaskInfo[In])
+}
+
+// WithHooks sets the pool's hooks, replacing any set before.
+func (p *Pool[In, Out]) WithHooks(hooks Hooks[In]) *Pool[In, Out] {
+	p.hooks = hooks
+	return p
+}
+
+func (p *Pool[In, Out]) hookEnqueue(input In, at time.Time) {
+	if p.hooks.OnEnqueue != nil {
+		p.hooks.OnEnqueue(TaskInfo[In]{Input: input, Worker: -1, Enqueued: at})
+	}
+}
+
+// enqueueAll marks a batch as enqueued now and returns that time.
+func (p *Pool[In, Out]) enqueueAll(inputs []In) time.Time {
+	now := time.Now()
+	for _, input := range inputs {
+		p.hookEnqueue(input, now)
+	}
+	return now
+}
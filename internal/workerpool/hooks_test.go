@@ -0,0 +1,118 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type hookLog struct {
+	mu       sync.Mutex
+	enqueued []TaskInfo[int]
+	started  []TaskInfo[int]
+	finished []TaskInfo[int]
+}
+
+func (l *hookLog) hooks() Hooks[int] {
+	add := func(list *[]TaskInfo[int]) func(TaskInfo[int]) {
+		return func(info TaskInfo[int]) {
+			l.mu.Lock()
+			*list = append(*list, info)
+			l.mu.Unlock()
+		}
+	}
+	return Hooks[int]{OnEnqueue: add(&l.enqueued), OnStart: add(&l.started), OnFinish: add(&l.finished)}
+}
+
+func TestPoolHooksBatch(t *testing.T) {
+	var log hookLog
+	pool := New[int, int](2).WithHooks(log.hooks())
+
+	pool.RunOrdered(context.Background(), []int{1, 2, 3, 4}, func(ctx context.Context, n int) int {
+		time.Sleep(10 * time.Millisecond)
+		return n
+	})
+
+	if len(log.enqueued) != 4 || len(log.started) != 4 || len(log.finished) != 4 {
+		t.Fatalf("hooks enqueue/start/finish = %d/%d/%d, want 4 each", len(log.enqueued), len(log.started), len(log.finished))
+	}
+	for _, info := range log.enqueued {
+		if info.Worker != -1 || info.Enqueued.IsZero() {
+			t.Fatalf("enqueue info = %+v", info)
+		}
+	}
+
+	var maxWait time.Duration
+	for _, info := range log.finished {
+		if info.Worker < 0 || info.Worker > 1 {
+			t.Fatalf("worker = %d, want 0 or 1", info.Worker)
+		}
+		if info.Run() < 10*time.Millisecond {
+			t.Fatalf("run = %s, want at least 10ms", info.Run())
+		}
+		maxWait = max(maxWait, info.Wait())
+	}
+	// Two workers, four 10ms tasks: the last two wait for a free worker.
+	if maxWait < 10*time.Millisecond {
+		t.Fatalf("max wait = %s, want queueing to be visible", maxWait)
+	}
+}
+
+func TestPoolHooksReportErrorsAndPanics(t *testing.T) {
+	var log hookLog
+	pool := New[int, int](1).WithHooks(log.hooks())
+	errOdd := errors.New("odd")
+
+	pool.RunE(context.Background(), []int{1, 2, 3}, CollectAll, func(ctx context.Context, n int) (int, error) {
+		switch n {
+		case 1:
+			return 0, errOdd
+		case 3:
+			panic("three")
+		}
+		return n, nil
+	})
+
+	errs := map[int]error{}
+	for _, info := range log.finished {
+		errs[info.Input] = info.Err
+	}
+	var panicErr *PanicError
+	if !errors.Is(errs[1], errOdd) || errs[2] != nil || !errors.As(errs[3], &panicErr) {
+		t.Fatalf("finish errors = %v", errs)
+	}
+}
+
+func TestPoolHooksSubmit(t *testing.T) {
+	var log hookLog
+	pool := New[int, int](2).WithHooks(log.hooks())
+	if err := pool.Start(double); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Submit(context.Background(), i); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if len(log.enqueued) != 3 || len(log.finished) != 3 {
+		t.Fatalf("hooks enqueue/finish = %d/%d, want 3 each", len(log.enqueued), len(log.finished))
+	}
+	for _, info := range log.finished {
+		if info.Enqueued.IsZero() || info.Started.Before(info.Enqueued) {
+			t.Fatalf("finish info = %+v", info)
+		}
+	}
+}
+
+func TestTaskInfoDurationsBeforeStart(t *testing.T) {
+	info := TaskInfo[int]{Enqueued: time.Now()}
+	if info.Wait() != 0 || info.Run() != 0 {
+		t.Fatalf("wait=%s run=%s, want 0 before the task starts", info.Wait(), info.Run())
+	}
+}
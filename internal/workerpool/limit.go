@@ -0,0 +1,254 @@
+package workerpool
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WithRateLimit caps how many tasks start per second across all workers.
+// burst tasks may start back to back before the limit applies; burst below
+// one is treated as one. A perSec of zero or less disables the limit.
+func (p *Pool[In, Out]) WithRateLimit(perSec float64, burst int) *Pool[In, Out] {
+	if perSec <= 0 {
+		p.rate = nil
+		return p
+	}
+	p.rate = newRateLimiter(perSec, max(burst, 1))
+	return p
+}
+
+// WithKeyLimit caps how many tasks sharing a key, as returned by key, run at
+// once. Batch runs hold back an input whose key is at its limit, up to
+// maxHeld of them, and hand later inputs with other keys to idle workers
+// meanwhile; a started pool's worker waits for a slot. A limit of zero or
+// less disables it.
+func (p *Pool[In, Out]) WithKeyLimit(key func(In) string, limit int) *Pool[In, Out] {
+	if key == nil || limit <= 0 {
+		p.keyOf, p.keys = nil, nil
+		return p
+	}
+	p.keyOf = key
+	p.keys = newKeyLimiter(limit)
+	return p
+}
+
+// admit waits until input may start under the pool's limits. The returned
+// release must be called when the task finishes. It fails with ctx's error
+// when ctx is done first, or with errDeadlinePassed once start.startBy,
+// unless zero, has passed. A key slot held on entry is released on failure.
+func (p *Pool[In, Out]) admit(ctx context.Context, input In, start taskStart) (release func(), err error) {
+	release = func() {}
+	var key string
+	if p.keys != nil {
+		key = p.keyOf(input)
+		if start.keyHeld {
+			release = func() { p.keys.release(key) }
+		}
+	}
+	fail := func(err error) (func(), error) {
+		release()
+		if ctx.Err() == nil && !start.startBy.IsZero() {
+			return nil, errDeadlinePassed
+		}
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+	waitCtx := ctx
+	if !start.startBy.IsZero() {
+		if !time.Now().Before(start.startBy) {
+			return fail(errDeadlinePassed)
+		}
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(ctx, start.startBy)
+		defer cancel()
+	}
+
+	if p.keys != nil && !start.keyHeld {
+		if err := p.keys.acquire(waitCtx, key); err != nil {
+			return fail(err)
+		}
+		release = func() { p.keys.release(key) }
+	}
+	if p.rate != nil {
+		if err := p.rate.wait(waitCtx); err != nil {
+			return fail(err)
+		}
+	}
+	if !start.startBy.IsZero() && time.Now().After(start.startBy) {
+		return fail(errDeadlinePassed)
+	}
+	return release, nil
+}
+
+// maxHeld caps how many inputs dispatchByKey holds back; once reached it
+// stops reading inputs until one of them can go.
+const maxHeld = 1024
+
+// dispatchByKey forwards each input once limiter has a free slot for its
+// key, taking the slot on behalf of the worker that receives it. Inputs
+// whose key is busy are held back in order while later inputs with other
+// keys go ahead, so no worker sits idle waiting on a busy key. Held inputs
+// are dropped when ctx is done. done is closed once dispatch has stopped.
+func dispatchByKey[T any](ctx context.Context, limiter *keyLimiter, key func(T) string, inputs <-chan T) (out <-chan T, done <-chan struct{}) {
+	dispatched := make(chan T)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer close(dispatched)
+		var held []T
+		for inputs != nil || len(held) > 0 {
+			// Taken before trying the held inputs, so a slot freed in
+			// between still wakes the wait below.
+			freed := limiter.freedSignal()
+
+			next := -1
+			busy := make(map[string]bool)
+			for i, item := range held {
+				k := key(item)
+				if !busy[k] && limiter.tryAcquire(k) {
+					next = i
+					break
+				}
+				busy[k] = true
+			}
+			if next >= 0 {
+				item := held[next]
+				held = slices.Delete(held, next, next+1)
+				select {
+				case dispatched <- item:
+				case <-ctx.Done():
+					limiter.release(key(item))
+					return
+				}
+				continue
+			}
+
+			receive := inputs
+			if len(held) >= maxHeld {
+				receive = nil
+			}
+			select {
+			case item, ok := <-receive:
+				if !ok {
+					inputs = nil
+					continue
+				}
+				held = append(held, item)
+			case <-freed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return dispatched, stopped
+}
+
+// rateLimiter is a token bucket. Waiters reserve a token up front, letting
+// the balance go negative, and sleep until it would have been refilled.
+type rateLimiter struct {
+	mu     sync.Mutex
+	perSec float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perSec float64, burst int) *rateLimiter {
+	return &rateLimiter{perSec: perSec, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.perSec)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.perSec * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		// The token would come too late; give it back now rather than
+		// sleeping until the deadline.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// keyLimiter counts running tasks per key.
+type keyLimiter struct {
+	mu    sync.Mutex
+	limit int
+	inUse map[string]int
+	// freed is closed and replaced whenever a slot is released.
+	freed chan struct{}
+}
+
+func newKeyLimiter(limit int) *keyLimiter {
+	return &keyLimiter{limit: limit, inUse: make(map[string]int), freed: make(chan struct{})}
+}
+
+// tryAcquire takes a slot for key if one is free.
+func (l *keyLimiter) tryAcquire(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inUse[key] >= l.limit {
+		return false
+	}
+	l.inUse[key]++
+	return true
+}
+
+// acquire waits for a slot for key.
+func (l *keyLimiter) acquire(ctx context.Context, key string) error {
+	for {
+		freed := l.freedSignal()
+		if l.tryAcquire(key) {
+			return nil
+		}
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *keyLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inUse[key]--
+	if l.inUse[key] == 0 {
+		delete(l.inUse, key)
+	}
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
+
+// freedSignal returns a channel closed at the next release.
+func (l *keyLimiter) freedSignal() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.freed
+}
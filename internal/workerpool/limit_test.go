@@ -0,0 +1,126 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolRateLimit(t *testing.T) {
+	pool := New[int, int](8).WithRateLimit(100, 2)
+	inputs := make([]int, 12)
+
+	start := time.Now()
+	results := pool.Run(context.Background(), inputs, func(ctx context.Context, n int) int { return n })
+	elapsed := time.Since(start)
+
+	if len(results) != len(inputs) {
+		t.Fatalf("got %d results, want %d", len(results), len(inputs))
+	}
+	// 2 tasks start immediately, the other 10 one every 10ms.
+	if elapsed < 90*time.Millisecond {
+		t.Fatalf("12 tasks at 100/s with burst 2 took %s, want at least ~100ms", elapsed)
+	}
+}
+
+func TestPoolRateLimitDisabled(t *testing.T) {
+	pool := New[int, int](2).WithRateLimit(1, 1).WithRateLimit(0, 0)
+	if pool.rate != nil {
+		t.Fatal("WithRateLimit(0, 0) should disable the limit")
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := newRateLimiter(1, 1)
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(ctx); err == nil {
+		t.Fatal("expected context error while waiting for a token")
+	}
+	if limiter.tokens < -0.5 {
+		t.Fatalf("tokens = %v, want the cancelled reservation returned", limiter.tokens)
+	}
+}
+
+func TestPoolKeyLimit(t *testing.T) {
+	type job struct {
+		host string
+		id   int
+	}
+	pool := New[job, string](8).WithKeyLimit(func(j job) string { return j.host }, 2)
+
+	var inputs []job
+	for i := 0; i < 6; i++ {
+		inputs = append(inputs, job{host: "a", id: i}, job{host: "b", id: i})
+	}
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	peak := map[string]int{}
+	pool.Run(context.Background(), inputs, func(ctx context.Context, j job) string {
+		mu.Lock()
+		running[j.host]++
+		peak[j.host] = max(peak[j.host], running[j.host])
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running[j.host]--
+		mu.Unlock()
+		return j.host
+	})
+
+	for _, host := range []string{"a", "b"} {
+		if peak[host] != 2 {
+			t.Errorf("peak concurrency for %s = %d, want 2", host, peak[host])
+		}
+	}
+	if len(pool.keys.inUse) != 0 {
+		t.Fatalf("key slots not released: %v", pool.keys.inUse)
+	}
+}
+
+func TestPoolKeyLimitDoesNotBlockOtherKeys(t *testing.T) {
+	pool := New[string, string](2).WithKeyLimit(func(host string) string { return host }, 1)
+
+	begin := time.Now()
+	var coldStarted time.Duration
+	pool.RunOrdered(context.Background(), []string{"hot", "hot", "cold"}, func(ctx context.Context, host string) string {
+		if host == "cold" {
+			coldStarted = time.Since(begin)
+			return host
+		}
+		time.Sleep(100 * time.Millisecond)
+		return host
+	})
+
+	// The second hot input waits for the first; the idle worker must take
+	// the cold one instead of waiting with it.
+	if coldStarted > 50*time.Millisecond {
+		t.Fatalf("cold input started after %s, want it not to wait behind the busy key", coldStarted)
+	}
+}
+
+func TestPoolKeyLimitCancelledWhileWaiting(t *testing.T) {
+	pool := New[int, int](2).WithKeyLimit(func(int) string { return "same" }, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	result := pool.RunE(ctx, []int{0, 1}, CollectAll, func(ctx context.Context, n int) (int, error) {
+		cancel()
+		<-ctx.Done()
+		return n, nil
+	})
+
+	if len(result.Skipped) != 1 {
+		t.Fatalf("skipped = %v, want the task blocked on its key", result.Skipped)
+	}
+	if len(pool.keys.inUse) != 0 {
+		t.Fatalf("key slots not released: %v", pool.keys.inUse)
+	}
+}
@@ -0,0 +1,124 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Scheduled is an input with scheduling hints for RunScheduled and
+// StreamScheduled.
+type Scheduled[T any] struct {
+	Value T
+	// Priority orders dispatch: higher values start first, ties keep input
+	// order.
+	Priority int
+	// Deadline is the latest time the task may start; zero means none.
+	Deadline time.Time
+}
+
+// Outcome is the result of a scheduled task.
+type Outcome[Out any] struct {
+	// Index is the job's position in the jobs slice.
+	Index int
+	Value Out
+	// Skipped reports that the deadline passed before the task could start,
+	// either waiting for a worker or for the pool's limits, so it never ran
+	// and Value is the zero value.
+	Skipped bool
+}
+
+// RunScheduled runs jobs highest priority first and returns outcomes
+// aligned to jobs. Positions whose task did not run because ctx was done
+// hold the zero Outcome.
+func (p *Pool[In, Out]) RunScheduled(ctx context.Context, jobs []Scheduled[In], fn TaskFunc[In, Out]) []Outcome[Out] {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	out := make([]Outcome[Out], len(jobs))
+	for result := range p.scheduled(ctx, jobs, fn) {
+		if result.ok {
+			out[result.index] = result.value
+		}
+	}
+	return out
+}
+
+// StreamScheduled is like RunScheduled but emits outcomes in job order as
+// soon as they and every earlier job have finished. Since dispatch follows
+// priority, a low-priority job early in the list holds back the jobs after
+// it; all outcomes are buffered until then.
+func (p *Pool[In, Out]) StreamScheduled(ctx context.Context, jobs []Scheduled[In], fn TaskFunc[In, Out]) <-chan Outcome[Out] {
+	done := p.scheduled(ctx, jobs, fn)
+	results := make(chan Outcome[Out])
+	go func() {
+		defer close(results)
+		pending := make(map[int]indexedOut[Outcome[Out]])
+		next := 0
+		for result := range done {
+			pending[result.index] = result
+			for {
+				out, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				if !out.ok {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case results <- out.value:
+				}
+			}
+		}
+	}()
+	return results
+}
+
+// scheduled dispatches jobs in priority order and emits their outcomes in
+// completion order.
+func (p *Pool[In, Out]) scheduled(ctx context.Context, jobs []Scheduled[In], fn TaskFunc[In, Out]) <-chan indexedOut[Outcome[Out]] {
+	order := make([]int, len(jobs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return jobs[order[a]].Priority > jobs[order[b]].Priority
+	})
+
+	indexed := make(chan Indexed[Scheduled[In]])
+	go func() {
+		defer close(indexed)
+		for _, i := range order {
+			select {
+			case <-ctx.Done():
+				return
+			case indexed <- Indexed[Scheduled[In]]{Index: i, Value: jobs[i]}:
+			}
+		}
+	}()
+
+	start := time.Now()
+	if p.hooks.OnEnqueue != nil {
+		for _, job := range jobs {
+			p.hookEnqueue(job.Value, start)
+		}
+	}
+
+	task := func(ctx context.Context, worker int, job Indexed[Scheduled[In]]) (indexedOut[Outcome[Out]], bool) {
+		outcome := Outcome[Out]{Index: job.Index}
+		out, err := p.call(ctx, fn, job.Value.Value, worker, taskStart{enqueued: start, startBy: job.Value.Deadline, keyHeld: p.keys != nil})
+		if errors.Is(err, errDeadlinePassed) {
+			outcome.Skipped = true
+			return indexedOut[Outcome[Out]]{index: job.Index, value: outcome, ok: true}, true
+		}
+		outcome.Value = out
+		return indexedOut[Outcome[Out]]{index: job.Index, value: outcome, ok: err == nil}, true
+	}
+	return batchStream(ctx, p, indexed, func(job Indexed[Scheduled[In]]) In { return job.Value.Value }, task)
+}
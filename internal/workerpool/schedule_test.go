@@ -0,0 +1,120 @@
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolRunScheduledPriorityOrder(t *testing.T) {
+	pool := New[string, string](1)
+	jobs := []Scheduled[string]{
+		{Value: "low", Priority: -1},
+		{Value: "normal-a"},
+		{Value: "core", Priority: 10},
+		{Value: "normal-b"},
+		{Value: "important", Priority: 5},
+	}
+
+	var mu sync.Mutex
+	var ran []string
+	outcomes := pool.RunScheduled(context.Background(), jobs, func(ctx context.Context, name string) string {
+		mu.Lock()
+		ran = append(ran, name)
+		mu.Unlock()
+		return "done-" + name
+	})
+
+	if fmt.Sprint(ran) != "[core important normal-a normal-b low]" {
+		t.Fatalf("execution order = %v", ran)
+	}
+	for i, outcome := range outcomes {
+		if outcome.Skipped || outcome.Index != i || outcome.Value != "done-"+jobs[i].Value {
+			t.Fatalf("outcomes[%d] = %+v, want aligned to jobs", i, outcome)
+		}
+	}
+}
+
+func TestPoolRunScheduledSkipsPastDeadline(t *testing.T) {
+	pool := New[int, int](1)
+	now := time.Now()
+	jobs := []Scheduled[int]{
+		{Value: 1, Priority: 1},
+		{Value: 2, Deadline: now.Add(10 * time.Millisecond)},
+		{Value: 3, Deadline: now.Add(time.Hour)},
+		{Value: 4},
+	}
+
+	outcomes := pool.RunScheduled(context.Background(), jobs, func(ctx context.Context, n int) int {
+		if n == 1 {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return n * 10
+	})
+
+	want := []Outcome[int]{{Index: 0, Value: 10}, {Index: 1, Skipped: true}, {Index: 2, Value: 30}, {Index: 3, Value: 40}}
+	if fmt.Sprint(outcomes) != fmt.Sprint(want) {
+		t.Fatalf("outcomes = %+v, want %+v", outcomes, want)
+	}
+}
+
+func TestPoolRunScheduledSkipsDeadlinePassedWaitingForLimits(t *testing.T) {
+	pool := New[int, int](8).WithRateLimit(5, 1)
+	deadline := time.Now().Add(300 * time.Millisecond)
+	jobs := make([]Scheduled[int], 8)
+	for i := range jobs {
+		jobs[i] = Scheduled[int]{Value: i, Deadline: deadline}
+	}
+
+	var mu sync.Mutex
+	var late []time.Time
+	begin := time.Now()
+	outcomes := pool.RunScheduled(context.Background(), jobs, func(ctx context.Context, n int) int {
+		if now := time.Now(); now.After(deadline) {
+			mu.Lock()
+			late = append(late, now)
+			mu.Unlock()
+		}
+		return n
+	})
+	elapsed := time.Since(begin)
+
+	skipped := 0
+	for _, outcome := range outcomes {
+		if outcome.Skipped {
+			skipped++
+		}
+	}
+	// One task starts at once and one 200ms later; the next token would
+	// come after the deadline.
+	if len(late) != 0 || skipped != 6 {
+		t.Fatalf("%d tasks started after the deadline, %d skipped; want 0 and 6", len(late), skipped)
+	}
+	if elapsed > 300*time.Millisecond {
+		t.Fatalf("run took %s, want skipped tasks not to wait out the deadline", elapsed)
+	}
+}
+
+func TestPoolStreamScheduledEmitsInJobOrder(t *testing.T) {
+	pool := New[int, int](2)
+	jobs := make([]Scheduled[int], 10)
+	for i := range jobs {
+		jobs[i] = Scheduled[int]{Value: i, Priority: i}
+	}
+
+	var got []int
+	for outcome := range pool.StreamScheduled(context.Background(), jobs, func(ctx context.Context, n int) int { return n }) {
+		got = append(got, outcome.Value)
+	}
+	if fmt.Sprint(got) != "[0 1 2 3 4 5 6 7 8 9]" {
+		t.Fatalf("outcomes = %v, want job order", got)
+	}
+}
+
+func TestPoolRunScheduledEmpty(t *testing.T) {
+	if got := New[int, int](1).RunScheduled(context.Background(), nil, double); got != nil {
+		t.Fatalf("got %v, want nil", got)
+	}
+}
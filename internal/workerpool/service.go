@@ -0,0 +1,361 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/itprodirect/go-hello-world/internal/metrics"
+)
+
+// QueuePolicy decides what Submit does when the queue is full.
+type QueuePolicy int
+
+const (
+	// Block makes Submit wait for queue space (backpressure).
+	Block QueuePolicy = iota
+	// Reject makes Submit fail with ErrQueueFull.
+	Reject
+)
+
+var (
+	// ErrQueueFull is returned by Submit under the Reject policy.
+	ErrQueueFull = errors.New("workerpool: queue full")
+	// ErrNotStarted is returned by Submit before Start.
+	ErrNotStarted = errors.New("workerpool: not started")
+	// ErrStarted is returned by Start when the pool is already running.
+	ErrStarted = errors.New("workerpool: already started")
+	// ErrClosed is returned once Shutdown has been called.
+	ErrClosed = errors.New("workerpool: shut down")
+)
+
+// Stats is a point-in-time view of a started pool.
+type Stats struct {
+	Workers   int
+	Queued    int
+	Active    int
+	Submitted uint64
+	Started   uint64
+	Completed uint64
+	Rejected  uint64
+}
+
+// Future is the pending output of a submitted task.
+type Future[Out any] struct {
+	done  chan struct{}
+	value Out
+	err   error
+}
+
+// Done is closed once the task has finished or was dropped.
+func (f *Future[Out]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the task finishes or ctx is done. The error is the
+// task's *PanicError when it panicked without a panic handler, or a context
+// error when the task was cancelled before it started.
+func (f *Future[Out]) Wait(ctx context.Context) (Out, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero Out
+		return zero, ctx.Err()
+	}
+}
+
+// WithQueue sets the Submit queue length and what happens when it is full.
+// The default is an unbuffered queue with the Block policy, so Submit waits
+// for an idle worker.
+func (p *Pool[In, Out]) WithQueue(size int, policy QueuePolicy) *Pool[In, Out] {
+	p.queueSize = max(size, 0)
+	p.queuePolicy = policy
+	return p
+}
+
+// WithCounters exports Submit activity as monotonic counters named
+// <prefix>_tasks_submitted, _started, _completed and _rejected, and the
+// current queue length and running tasks as gauges named
+// <prefix>_tasks_queued and _active. It panics if a gauge name is taken by
+// another kind of metric.
+func (p *Pool[In, Out]) WithCounters(counters *metrics.Counters, prefix string) *Pool[In, Out] {
+	p.counters = counters
+	p.prefix = prefix
+	p.queued, p.active = nil, nil
+	if counters != nil {
+		p.queued = counters.MustGauge(prefix + "_tasks_queued")
+		p.active = counters.MustGauge(prefix + "_tasks_active")
+	}
+	return p
+}
+
+type job[In any, Out any] struct {
+	ctx      context.Context
+	input    In
+	enqueued time.Time
+	future   *Future[Out]
+}
+
+// service is the state of a started pool.
+type service[In any, Out any] struct {
+	fn      TaskFunc[In, Out]
+	queue   chan job[In, Out]
+	running int
+	nextID  int
+	// shrink is closed and replaced by Resize to wake idle workers so the
+	// surplus can exit.
+	shrink chan struct{}
+
+	closed  bool
+	closing chan struct{}
+	sending sync.WaitGroup
+	workers sync.WaitGroup
+
+	// ctx is cancelled when Shutdown gives up waiting.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	active    atomic.Int64
+	submitted atomic.Uint64
+	started   atomic.Uint64
+	completed atomic.Uint64
+	rejected  atomic.Uint64
+}
+
+// Start launches the pool's workers to run fn for every input passed to
+// Submit. A pool can be started once.
+func (p *Pool[In, Out]) Start(fn TaskFunc[In, Out]) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.svc != nil {
+		if p.svc.closed {
+			return ErrClosed
+		}
+		return ErrStarted
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.svc = &service[In, Out]{
+		fn:      fn,
+		queue:   make(chan job[In, Out], p.queueSize),
+		shrink:  make(chan struct{}),
+		closing: make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	p.spawn(p.workers)
+	return nil
+}
+
+// Submit queues input and returns a future for its output. The task runs
+// with ctx, which also bounds how long Submit blocks for queue space.
+func (p *Pool[In, Out]) Submit(ctx context.Context, input In) (*Future[Out], error) {
+	p.mu.Lock()
+	svc := p.svc
+	if svc == nil {
+		p.mu.Unlock()
+		return nil, ErrNotStarted
+	}
+	if svc.closed {
+		p.mu.Unlock()
+		return nil, ErrClosed
+	}
+	svc.sending.Add(1)
+	p.mu.Unlock()
+	defer svc.sending.Done()
+
+	j := job[In, Out]{ctx: ctx, input: input, enqueued: time.Now(), future: &Future[Out]{done: make(chan struct{})}}
+	if p.queuePolicy == Reject {
+		select {
+		case svc.queue <- j:
+		default:
+			svc.rejected.Add(1)
+			p.count("rejected")
+			return nil, ErrQueueFull
+		}
+	} else {
+		select {
+		case svc.queue <- j:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-svc.closing:
+			return nil, ErrClosed
+		}
+	}
+
+	svc.submitted.Add(1)
+	p.count("submitted")
+	p.gaugeQueue(svc)
+	p.hookEnqueue(input, j.enqueued)
+	return j.future, nil
+}
+
+// Resize changes the worker count, to at least one, for later batch runs
+// and for a started pool. On a started pool new workers start at once and
+// surplus workers exit after their current task.
+func (p *Pool[In, Out]) Resize(workers int) {
+	workers = max(workers, 1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.workers = workers
+	if p.svc == nil || p.svc.closed {
+		return
+	}
+	if workers > p.svc.running {
+		p.spawn(workers - p.svc.running)
+		return
+	}
+	close(p.svc.shrink)
+	p.svc.shrink = make(chan struct{})
+}
+
+// Shutdown stops accepting submissions and waits for queued and in-flight
+// tasks to finish. If ctx is done first, the contexts of running tasks are
+// cancelled, tasks still queued are dropped with ErrClosed, and ctx's error
+// is returned.
+func (p *Pool[In, Out]) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	svc := p.svc
+	if svc == nil {
+		p.mu.Unlock()
+		return ErrNotStarted
+	}
+	if !svc.closed {
+		svc.closed = true
+		close(svc.closing)
+		// Blocked submitters return promptly once closing is closed.
+		svc.sending.Wait()
+		close(svc.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		svc.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		svc.cancel()
+		return nil
+	case <-ctx.Done():
+		svc.cancel()
+		return ctx.Err()
+	}
+}
+
+// Stats reports the state of a started pool; it is zero before Start.
+func (p *Pool[In, Out]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	svc := p.svc
+	if svc == nil {
+		return Stats{}
+	}
+	return Stats{
+		Workers:   svc.running,
+		Queued:    len(svc.queue),
+		Active:    int(svc.active.Load()),
+		Submitted: svc.submitted.Load(),
+		Started:   svc.started.Load(),
+		Completed: svc.completed.Load(),
+		Rejected:  svc.rejected.Load(),
+	}
+}
+
+// spawn starts n workers. p.mu must be held.
+func (p *Pool[In, Out]) spawn(n int) {
+	svc := p.svc
+	svc.running += n
+	svc.workers.Add(n)
+	for i := 0; i < n; i++ {
+		go p.serve(svc, svc.nextID)
+		svc.nextID++
+	}
+}
+
+func (p *Pool[In, Out]) serve(svc *service[In, Out], worker int) {
+	defer svc.workers.Done()
+	for {
+		p.mu.Lock()
+		if svc.running > p.workers && !svc.closed {
+			svc.running--
+			p.mu.Unlock()
+			return
+		}
+		shrink := svc.shrink
+		p.mu.Unlock()
+
+		select {
+		case j, ok := <-svc.queue:
+			if !ok {
+				p.mu.Lock()
+				svc.running--
+				p.mu.Unlock()
+				return
+			}
+			p.gaugeQueue(svc)
+			p.runJob(svc, worker, j)
+		case <-shrink:
+		}
+	}
+}
+
+func (p *Pool[In, Out]) runJob(svc *service[In, Out], worker int, j job[In, Out]) {
+	defer close(j.future.done)
+
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(svc.ctx, cancel)
+	defer stop()
+
+	if svc.ctx.Err() != nil {
+		j.future.err = ErrClosed
+		return
+	}
+	if err := ctx.Err(); err != nil {
+		j.future.err = err
+		return
+	}
+
+	svc.active.Add(1)
+	if p.active != nil {
+		p.active.Inc()
+	}
+	svc.started.Add(1)
+	p.count("started")
+
+	j.future.value, j.future.err = p.call(ctx, svc.fn, j.input, worker, taskStart{enqueued: j.enqueued})
+
+	svc.active.Add(-1)
+	if p.active != nil {
+		p.active.Dec()
+	}
+	svc.completed.Add(1)
+	p.count("completed")
+}
+
+func (p *Pool[In, Out]) count(event string) {
+	if p.counters != nil {
+		p.counters.Inc(p.prefix + "_tasks_" + event)
+	}
+}
+
+// gaugeQueue updates the queued gauge after the queue changed. Reading and
+// setting under p.mu keeps a stale length from overwriting a newer one.
+func (p *Pool[In, Out]) gaugeQueue(svc *service[In, Out]) {
+	if p.queued == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queued.Set(float64(len(svc.queue)))
+}
@@ -0,0 +1,249 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/itprodirect/go-hello-world/internal/metrics"
+)
+
+func double(ctx context.Context, n int) int {
+	return n * 2
+}
+
+func TestPoolSubmitAndWait(t *testing.T) {
+	counters := metrics.NewCounters()
+	pool := New[int, int](2).WithCounters(counters, "test")
+	if err := pool.Start(double); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	var futures []*Future[int]
+	for i := 0; i < 10; i++ {
+		future, err := pool.Submit(context.Background(), i)
+		if err != nil {
+			t.Fatalf("Submit(%d): %v", i, err)
+		}
+		futures = append(futures, future)
+	}
+	for i, future := range futures {
+		got, err := future.Wait(context.Background())
+		if err != nil || got != i*2 {
+			t.Fatalf("future %d = %d, %v; want %d", i, got, err, i*2)
+		}
+	}
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	stats := pool.Stats()
+	if stats.Submitted != 10 || stats.Started != 10 || stats.Completed != 10 || stats.Active != 0 || stats.Queued != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if counters.Get("test_tasks_submitted") != 10 || counters.Get("test_tasks_completed") != 10 {
+		t.Fatalf("counters = %v", counters.Snapshot())
+	}
+	if samples := counters.Samples(); samples["test_tasks_queued"] != 0 || samples["test_tasks_active"] != 0 {
+		t.Fatalf("gauges = %v, want an idle pool", samples)
+	}
+}
+
+func TestPoolSubmitLifecycleErrors(t *testing.T) {
+	pool := New[int, int](1)
+	if _, err := pool.Submit(context.Background(), 1); !errors.Is(err, ErrNotStarted) {
+		t.Fatalf("Submit before Start = %v, want ErrNotStarted", err)
+	}
+	if err := pool.Shutdown(context.Background()); !errors.Is(err, ErrNotStarted) {
+		t.Fatalf("Shutdown before Start = %v, want ErrNotStarted", err)
+	}
+
+	if err := pool.Start(double); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := pool.Start(double); !errors.Is(err, ErrStarted) {
+		t.Fatalf("second Start = %v, want ErrStarted", err)
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if _, err := pool.Submit(context.Background(), 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Submit after Shutdown = %v, want ErrClosed", err)
+	}
+	if err := pool.Start(double); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Start after Shutdown = %v, want ErrClosed", err)
+	}
+}
+
+func TestPoolSubmitRejectsWhenQueueFull(t *testing.T) {
+	counters := metrics.NewCounters()
+	release := make(chan struct{})
+	pool := New[int, int](1).WithQueue(1, Reject).WithCounters(counters, "svc")
+	if err := pool.Start(func(ctx context.Context, n int) int {
+		<-release
+		return n
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// Fill the worker and the queue slot.
+	running, err := pool.Submit(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitFor(t, func() bool { return pool.Stats().Active == 1 })
+	if _, err := pool.Submit(context.Background(), 2); err != nil {
+		t.Fatalf("Submit to queue: %v", err)
+	}
+
+	if _, err := pool.Submit(context.Background(), 3); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit = %v, want ErrQueueFull", err)
+	}
+	if stats := pool.Stats(); stats.Rejected != 1 || stats.Queued != 1 {
+		t.Fatalf("stats = %+v, want 1 rejected and 1 queued", stats)
+	}
+	if counters.Get("svc_tasks_rejected") != 1 {
+		t.Fatalf("counters = %v", counters.Snapshot())
+	}
+	samples := counters.Samples()
+	if samples["svc_tasks_queued"] != 1 || samples["svc_tasks_active"] != 1 {
+		t.Fatalf("gauges queued=%v active=%v, want 1 and 1", samples["svc_tasks_queued"], samples["svc_tasks_active"])
+	}
+
+	close(release)
+	if _, err := running.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if got := pool.Stats().Completed; got != 2 {
+		t.Fatalf("completed = %d, want 2 (queued task drained)", got)
+	}
+}
+
+func TestPoolSubmitBlocksUntilContextDone(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	pool := New[int, int](1)
+	if err := pool.Start(func(ctx context.Context, n int) int {
+		<-release
+		return n
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := pool.Submit(context.Background(), 1); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Submit(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Submit = %v, want DeadlineExceeded from backpressure", err)
+	}
+}
+
+func TestPoolResize(t *testing.T) {
+	var current, peak atomic.Int32
+	release := make(chan struct{})
+	pool := New[int, int](1).WithQueue(16, Block)
+	if err := pool.Start(func(ctx context.Context, n int) int {
+		c := current.Add(1)
+		for {
+			old := peak.Load()
+			if c <= old || peak.CompareAndSwap(old, c) {
+				break
+			}
+		}
+		<-release
+		current.Add(-1)
+		return n
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	pool.Resize(4)
+	for i := 0; i < 8; i++ {
+		if _, err := pool.Submit(context.Background(), i); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	waitFor(t, func() bool { return current.Load() == 4 })
+	close(release)
+
+	pool.Resize(2)
+	waitFor(t, func() bool { return pool.Stats().Workers == 2 })
+	if pool.Workers() != 2 {
+		t.Fatalf("Workers() = %d, want 2", pool.Workers())
+	}
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if peak.Load() != 4 {
+		t.Fatalf("peak concurrency = %d, want 4", peak.Load())
+	}
+	if got := pool.Stats().Completed; got != 8 {
+		t.Fatalf("completed = %d, want 8", got)
+	}
+}
+
+func TestPoolShutdownTimeoutCancelsTasks(t *testing.T) {
+	pool := New[int, int](1).WithQueue(4, Block)
+	started := make(chan struct{})
+	if err := pool.Start(func(ctx context.Context, n int) int {
+		close(started)
+		<-ctx.Done()
+		return -1
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	running, _ := pool.Submit(context.Background(), 1)
+	queued, _ := pool.Submit(context.Background(), 2)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+
+	if got, err := running.Wait(context.Background()); err != nil || got != -1 {
+		t.Fatalf("running task = %d, %v; want it cancelled and finished", got, err)
+	}
+	if _, err := queued.Wait(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("queued task err = %v, want ErrClosed", err)
+	}
+}
+
+func TestPoolSubmitPanicResolvesFuture(t *testing.T) {
+	pool := New[int, int](1)
+	if err := pool.Start(func(ctx context.Context, n int) int { panic("bad input") }); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer pool.Shutdown(context.Background())
+
+	future, err := pool.Submit(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	var panicErr *PanicError
+	if _, err := future.Wait(context.Background()); !errors.As(err, &panicErr) {
+		t.Fatalf("Wait = %v, want *PanicError", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,123 @@
+package workerpool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TraceRecorder collects finished tasks from Hooks and writes them in the
+// Chrome trace-event format, viewable in chrome://tracing or Perfetto.
+type TraceRecorder[In any] struct {
+	name  func(In) string
+	start time.Time
+
+	mu    sync.Mutex
+	spans []traceSpan
+}
+
+type traceSpan struct {
+	name     string
+	worker   int
+	enqueued time.Time
+	started  time.Time
+	finished time.Time
+	err      string
+}
+
+// NewTraceRecorder returns a recorder that labels tasks with name. Trace
+// timestamps are relative to the recorder's creation.
+func NewTraceRecorder[In any](name func(In) string) *TraceRecorder[In] {
+	return &TraceRecorder[In]{name: name, start: time.Now()}
+}
+
+// Hooks returns the hooks that feed the recorder.
+func (r *TraceRecorder[In]) Hooks() Hooks[In] {
+	return Hooks[In]{OnFinish: r.record}
+}
+
+func (r *TraceRecorder[In]) record(info TaskInfo[In]) {
+	span := traceSpan{
+		name:     r.name(info.Input),
+		worker:   info.Worker,
+		enqueued: info.Enqueued,
+		started:  info.Started,
+		finished: info.Finished,
+	}
+	if info.Err != nil {
+		span.err = info.Err.Error()
+	}
+
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+}
+
+// traceEvent is one entry of the trace-event format.
+type traceEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	Ts   int64          `json:"ts"`
+	Dur  int64          `json:"dur,omitempty"`
+	Pid  int            `json:"pid"`
+	Tid  int            `json:"tid"`
+	ID   int            `json:"id,omitempty"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the recorded tasks as a trace: one thread per
+// worker with a complete event per run, plus an async "queue" event per
+// task covering its wait.
+func (r *TraceRecorder[In]) WriteChromeTrace(w io.Writer) error {
+	r.mu.Lock()
+	spans := append([]traceSpan(nil), r.spans...)
+	r.mu.Unlock()
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].started.Before(spans[j].started) })
+
+	micros := func(t time.Time) int64 { return t.Sub(r.start).Microseconds() }
+	events := []traceEvent{{Name: "process_name", Ph: "M", Pid: 1, Args: map[string]any{"name": "workerpool"}}}
+
+	workers := map[int]bool{}
+	for i, span := range spans {
+		if !workers[span.worker] {
+			workers[span.worker] = true
+			events = append(events, traceEvent{
+				Name: "thread_name", Ph: "M", Pid: 1, Tid: span.worker,
+				Args: map[string]any{"name": fmt.Sprintf("worker %d", span.worker)},
+			})
+		}
+
+		args := map[string]any{
+			"wait_ms": float64(span.started.Sub(span.enqueued).Microseconds()) / 1000,
+			"run_ms":  float64(span.finished.Sub(span.started).Microseconds()) / 1000,
+		}
+		if span.err != "" {
+			args["error"] = span.err
+		}
+		events = append(events,
+			traceEvent{
+				Name: span.name, Cat: "queue", Ph: "b", Ts: micros(span.enqueued),
+				Pid: 1, Tid: span.worker, ID: i + 1,
+			},
+			traceEvent{
+				Name: span.name, Cat: "queue", Ph: "e", Ts: micros(span.started),
+				Pid: 1, Tid: span.worker, ID: i + 1,
+			},
+			traceEvent{
+				Name: span.name, Cat: "task", Ph: "X", Ts: micros(span.started),
+				Dur: max(span.finished.Sub(span.started).Microseconds(), 1),
+				Pid: 1, Tid: span.worker, Args: args,
+			},
+		)
+	}
+
+	return json.NewEncoder(w).Encode(map[string]any{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	})
+}
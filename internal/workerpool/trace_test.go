@@ -0,0 +1,66 @@
+package workerpool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTraceRecorderWritesChromeTrace(t *testing.T) {
+	recorder := NewTraceRecorder(func(n int) string { return fmt.Sprintf("task-%d", n) })
+	pool := New[int, int](2).WithHooks(recorder.Hooks())
+
+	pool.Run(context.Background(), []int{1, 2, 3}, func(ctx context.Context, n int) int {
+		time.Sleep(5 * time.Millisecond)
+		if n == 3 {
+			panic("boom")
+		}
+		return n
+	})
+
+	var buf bytes.Buffer
+	if err := recorder.WriteChromeTrace(&buf); err != nil {
+		t.Fatalf("WriteChromeTrace: %v", err)
+	}
+
+	var trace struct {
+		TraceEvents []struct {
+			Name string         `json:"name"`
+			Ph   string         `json:"ph"`
+			Ts   int64          `json:"ts"`
+			Dur  int64          `json:"dur"`
+			Tid  int            `json:"tid"`
+			Args map[string]any `json:"args"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatalf("decode trace %q: %v", buf.String(), err)
+	}
+
+	phases := map[string]int{}
+	runs := map[string]map[string]any{}
+	for _, event := range trace.TraceEvents {
+		phases[event.Ph]++
+		if event.Ph == "X" {
+			if event.Dur < 5000 {
+				t.Errorf("%s dur = %dus, want >= 5000", event.Name, event.Dur)
+			}
+			runs[event.Name] = event.Args
+		}
+	}
+	if phases["X"] != 3 || phases["b"] != 3 || phases["e"] != 3 {
+		t.Fatalf("event phases = %v, want 3 run and 3 queue pairs", phases)
+	}
+	if phases["M"] < 2 {
+		t.Fatalf("event phases = %v, want process and thread names", phases)
+	}
+	if _, ok := runs["task-1"]["wait_ms"]; !ok {
+		t.Fatalf("task-1 args = %v, want wait_ms", runs["task-1"])
+	}
+	if runs["task-3"]["error"] != "panic: boom" {
+		t.Fatalf("task-3 args = %v, want the panic recorded", runs["task-3"])
+	}
+}
@@ -2,15 +2,102 @@ package workerpool
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/itprodirect/go-hello-world/internal/metrics"
 )
 
 // TaskFunc processes one input item and returns one output item.
 type TaskFunc[In any, Out any] func(ctx context.Context, input In) Out
 
-// Pool runs tasks with a fixed worker count.
+// ErrTaskFunc processes one input item and may fail.
+type ErrTaskFunc[In any, Out any] func(ctx context.Context, input In) (Out, error)
+
+// ErrorPolicy is the number of task errors RunE tolerates before it cancels
+// the remaining tasks. Zero never cancels.
+type ErrorPolicy int
+
+const (
+	// CollectAll runs every task and reports all errors.
+	CollectAll ErrorPolicy = 0
+	// FailFast cancels the remaining tasks after the first error.
+	FailFast ErrorPolicy = 1
+)
+
+// MaxErrors cancels the remaining tasks once n tasks have failed.
+func MaxErrors(n int) ErrorPolicy {
+	return ErrorPolicy(max(n, 0))
+}
+
+// TaskError records the failure of the task for inputs[Index].
+type TaskError struct {
+	Index int
+	Err   error
+}
+
+func (e TaskError) Error() string {
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
+
+func (e TaskError) Unwrap() error {
+	return e.Err
+}
+
+// BatchResult is the outcome of RunE.
+type BatchResult[Out any] struct {
+	// Outputs is aligned to the inputs; failed and skipped tasks leave
+	// whatever their task returned, or the zero value.
+	Outputs []Out
+	// Errors lists failed tasks in input order.
+	Errors []TaskError
+	// Skipped lists the input indexes whose task never ran because the
+	// batch was cancelled.
+	Skipped []int
+	// Err joins every task error, plus the context error when the caller's
+	// context cut the batch short. It is nil when every task succeeded.
+	Err error
+}
+
+// PanicError is a panic recovered from a task.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Pool runs tasks with a fixed worker count, either as one-shot batches
+// (Run, Stream and friends) or as a long-lived service (Start and Submit).
 type Pool[In any, Out any] struct {
+	onPanic func(In, *PanicError) Out
+	rate    *rateLimiter
+	keyOf   func(In) string
+	keys    *keyLimiter
+	hooks   Hooks[In]
+
+	mu      sync.Mutex
 	workers int
+	svc     *service[In, Out]
+
+	queueSize   int
+	queuePolicy QueuePolicy
+	counters    *metrics.Counters
+	prefix      string
+	queued      *metrics.Gauge
+	active      *metrics.Gauge
 }
 
 // New returns a pool with at least one worker.
@@ -21,28 +108,374 @@ func New[In any, Out any](workers int) *Pool[In, Out] {
 	return &Pool[In, Out]{workers: workers}
 }
 
-// Run fans out inputs and collects outputs in arbitrary order.
+// Workers returns the current worker count.
+func (p *Pool[In, Out]) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.workers
+}
+
+// WithPanicHandler sets the function that turns a panicking task into an
+// output. A panic never stops a worker: without a handler the task simply
+// produces no output (Run and the streams omit it, RunOrdered leaves the
+// zero value). RunE always reports panics as task errors.
+func (p *Pool[In, Out]) WithPanicHandler(handler func(In, *PanicError) Out) *Pool[In, Out] {
+	p.onPanic = handler
+	return p
+}
+
+// Indexed pairs a value with its position in the input sequence.
+type Indexed[T any] struct {
+	Index int
+	Value T
+}
+
+// Run fans out inputs and collects outputs in completion order.
 func (p *Pool[In, Out]) Run(ctx context.Context, inputs []In, fn TaskFunc[In, Out]) []Out {
 	if len(inputs) == 0 {
 		return nil
 	}
 
-	jobs := make(chan In)
-	results := make(chan Out, len(inputs))
+	start := p.enqueueAll(inputs)
+	out := make([]Out, 0, len(inputs))
+	for result := range batchStream(ctx, p, Source(ctx, inputs), identity[In], p.guard(fn, start)) {
+		out = append(out, result)
+	}
+
+	return out
+}
+
+// Stream runs fn for each value received from inputs and emits each output
+// as soon as its task finishes, in completion order. At most one output per
+// worker is held at a time, so unbounded inputs run in bounded memory.
+//
+// The returned channel is closed once inputs is closed or ctx is done and
+// all in-flight tasks have returned. Callers must drain it or cancel ctx;
+// outputs of tasks that finish after ctx is done are dropped.
+func (p *Pool[In, Out]) Stream(ctx context.Context, inputs <-chan In, fn TaskFunc[In, Out]) <-chan Out {
+	return batchStream(ctx, p, inputs, identity[In], p.guard(fn, time.Time{}))
+}
+
+// RunOrdered is like Run but returns outputs aligned to input positions:
+// out[i] is the result of inputs[i]. If ctx is done before every task has
+// started, positions whose task did not run hold the zero value.
+func (p *Pool[In, Out]) RunOrdered(ctx context.Context, inputs []In, fn TaskFunc[In, Out]) []Out {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	start := p.enqueueAll(inputs)
+	out := make([]Out, len(inputs))
+	indexed := make(chan Indexed[In])
+	go func() {
+		defer close(indexed)
+		for i, input := range inputs {
+			select {
+			case <-ctx.Done():
+				return
+			case indexed <- Indexed[In]{Index: i, Value: input}:
+			}
+		}
+	}()
+
+	for result := range batchStream(ctx, p, indexed, indexedValue[In], indexedTask(p.guard(fn, start))) {
+		if result.ok {
+			out[result.index] = result.value
+		}
+	}
+	return out
+}
+
+// RunE runs an error-returning task for each input. Once policy's error
+// threshold is reached the context passed to running tasks is cancelled and
+// no further tasks start. Outputs of tasks that ran are always kept, even if
+// ctx is cancelled while they run.
+func (p *Pool[In, Out]) RunE(ctx context.Context, inputs []In, policy ErrorPolicy, fn ErrTaskFunc[In, Out]) BatchResult[Out] {
+	result := BatchResult[Out]{Outputs: make([]Out, len(inputs))}
+	if len(inputs) == 0 {
+		return result
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := p.enqueueAll(inputs)
+	ran := make([]bool, len(inputs))
+	var mu sync.Mutex
+	task := func(ctx context.Context, worker int, item Indexed[In]) (struct{}, bool) {
+		out, err := p.run(ctx, fn, item.Value, worker, taskStart{enqueued: start, keyHeld: p.keys != nil})
+		if errors.Is(err, errNotStarted) {
+			return struct{}{}, false
+		}
+		ran[item.Index] = true
+		result.Outputs[item.Index] = out
+		if err != nil {
+			mu.Lock()
+			result.Errors = append(result.Errors, TaskError{Index: item.Index, Err: err})
+			failed := len(result.Errors)
+			mu.Unlock()
+			if policy > 0 && failed >= int(policy) {
+				cancel()
+			}
+		}
+		return struct{}{}, false
+	}
+
+	indexed := make(chan Indexed[In])
+	go func() {
+		defer close(indexed)
+		for i, input := range inputs {
+			select {
+			case <-runCtx.Done():
+				return
+			case indexed <- Indexed[In]{Index: i, Value: input}:
+			}
+		}
+	}()
+	for range batchStream(runCtx, p, indexed, indexedValue[In], task) {
+	}
+
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Index < result.Errors[j].Index })
+	for i, ok := range ran {
+		if !ok {
+			result.Skipped = append(result.Skipped, i)
+		}
+	}
+
+	errs := make([]error, 0, len(result.Errors)+1)
+	for _, taskErr := range result.Errors {
+		errs = append(errs, taskErr)
+	}
+	if len(result.Skipped) > 0 && ctx.Err() != nil {
+		errs = append(errs, fmt.Errorf("%d tasks skipped: %w", len(result.Skipped), ctx.Err()))
+	}
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// StreamOrdered is like Stream but emits outputs in input order. A result
+// that finishes ahead of an earlier one is held until the earlier one is
+// emitted. window caps how many inputs may be in flight or held at once,
+// which bounds the reorder buffer; values below the worker count are
+// raised to it, and zero means twice the worker count.
+//
+// A slow task stalls emission, and once the window fills, dispatch, until
+// it finishes.
+func (p *Pool[In, Out]) StreamOrdered(ctx context.Context, inputs <-chan In, window int, fn TaskFunc[In, Out]) <-chan Out {
+	workers := p.Workers()
+	if window <= 0 {
+		window = 2 * workers
+	}
+	window = max(window, workers)
+
+	slots := make(chan struct{}, window)
+	indexed := make(chan Indexed[In])
+	go func() {
+		defer close(indexed)
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case slots <- struct{}{}:
+			}
+
+			var input In
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case input, ok = <-inputs:
+				if !ok {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case indexed <- Indexed[In]{Index: i, Value: input}:
+			}
+		}
+	}()
+
+	done := batchStream(ctx, p, indexed, indexedValue[In], indexedTask(p.guard(fn, time.Time{})))
+	results := make(chan Out)
+	go func() {
+		defer close(results)
+		pending := make(map[int]indexedOut[Out], window)
+		next := 0
+		for result := range done {
+			pending[result.index] = result
+			for {
+				out, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				if out.ok {
+					select {
+					case <-ctx.Done():
+						return
+					case results <- out.value:
+					}
+				}
+				next++
+				<-slots
+			}
+		}
+	}()
+
+	return results
+}
+
+var (
+	// errNotStarted wraps the context error of a task that was cancelled
+	// while waiting for the pool's limits.
+	errNotStarted = errors.New("task not started")
+	// errDeadlinePassed is returned for a task whose start deadline passed
+	// before it could start.
+	errDeadlinePassed = errors.New("start deadline passed")
+)
+
+// guard adapts fn for stream; it reports false when the task produced no
+// output. Tasks count as enqueued at batchStart or, when it is zero, once a
+// worker receives them. See call.
+func (p *Pool[In, Out]) guard(fn TaskFunc[In, Out], batchStart time.Time) func(context.Context, int, In) (Out, bool) {
+	return func(ctx context.Context, worker int, input In) (Out, bool) {
+		enqueued := batchStart
+		if enqueued.IsZero() {
+			enqueued = time.Now()
+			p.hookEnqueue(input, enqueued)
+		}
+		out, err := p.call(ctx, fn, input, worker, taskStart{enqueued: enqueued, keyHeld: p.keys != nil})
+		return out, err == nil
+	}
+}
+
+// call is run for a TaskFunc, converting panics with the panic handler
+// when one is set.
+func (p *Pool[In, Out]) call(ctx context.Context, fn TaskFunc[In, Out], input In, worker int, start taskStart) (Out, error) {
+	out, err := p.run(ctx, func(ctx context.Context, input In) (Out, error) {
+		return fn(ctx, input), nil
+	}, input, worker, start)
+
+	var panicErr *PanicError
+	if p.onPanic != nil && errors.As(err, &panicErr) {
+		return p.onPanic(input, panicErr), nil
+	}
+	return out, err
+}
+
+// taskStart describes how a task reached its worker.
+type taskStart struct {
+	enqueued time.Time
+	// startBy, unless zero, is the latest time the task may start.
+	startBy time.Time
+	// keyHeld reports that dispatchByKey already took the task's key slot.
+	keyHeld bool
+}
+
+// run applies the pool's limits to fn, reports it to the hooks and
+// recovers its panics. The error is fn's, a *PanicError, errDeadlinePassed,
+// or errNotStarted wrapping the context error if ctx was done before the
+// task could start.
+func (p *Pool[In, Out]) run(ctx context.Context, fn ErrTaskFunc[In, Out], input In, worker int, start taskStart) (Out, error) {
+	var zero Out
+	release, err := p.admit(ctx, input, start)
+	if errors.Is(err, errDeadlinePassed) {
+		return zero, err
+	}
+	if err != nil {
+		return zero, fmt.Errorf("%w: %w", errNotStarted, err)
+	}
+	defer release()
+
+	info := TaskInfo[In]{Input: input, Worker: worker, Enqueued: start.enqueued, Started: time.Now()}
+	if p.hooks.OnStart != nil {
+		p.hooks.OnStart(info)
+	}
+
+	out, err := callSafely(func() (Out, error) { return fn(ctx, input) })
+
+	if p.hooks.OnFinish != nil {
+		info.Finished = time.Now()
+		info.Err = err
+		p.hooks.OnFinish(info)
+	}
+	return out, err
+}
+
+// callSafely runs call, returning a *PanicError if it panics.
+func callSafely[Out any](call func() (Out, error)) (out Out, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return call()
+}
+
+type indexedOut[Out any] struct {
+	index int
+	value Out
+	ok    bool
+}
+
+func indexedValue[T any](item Indexed[T]) T {
+	return item.Value
+}
+
+func identity[T any](v T) T {
+	return v
+}
+
+func indexedTask[In any, Out any](fn func(context.Context, int, In) (Out, bool)) func(context.Context, int, Indexed[In]) (indexedOut[Out], bool) {
+	return func(ctx context.Context, worker int, input Indexed[In]) (indexedOut[Out], bool) {
+		value, ok := fn(ctx, worker, input.Value)
+		return indexedOut[Out]{index: input.Index, value: value, ok: ok}, true
+	}
+}
+
+// batchStream runs stream for a batch of p. With a key limit, inputs go
+// through dispatchByKey first; input extracts the pool input from an item.
+func batchStream[In any, Out any, T any, R any](ctx context.Context, p *Pool[In, Out], inputs <-chan T, input func(T) In, fn func(context.Context, int, T) (R, bool)) <-chan R {
+	if p.keys == nil {
+		return stream(ctx, p.Workers(), inputs, nil, fn)
+	}
+	dispatched, done := dispatchByKey(ctx, p.keys, func(item T) string { return p.keyOf(input(item)) }, inputs)
+	return stream(ctx, p.Workers(), dispatched, done, fn)
+}
+
+// stream runs fn on workers goroutines reading from inputs; see Stream.
+// fn is passed the worker's id. Outputs for which fn reports false are not
+// emitted. Unless after is nil, the output channel is not closed before it
+// is.
+func stream[In any, Out any](ctx context.Context, workers int, inputs <-chan In, after <-chan struct{}, fn func(context.Context, int, In) (Out, bool)) <-chan Out {
+	results := make(chan Out)
 
 	var wg sync.WaitGroup
-	for i := 0; i < p.workers; i++ {
+	for worker := 0; worker < workers; worker++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for input := range jobs {
+			for {
+				var input In
+				var ok bool
 				select {
 				case <-ctx.Done():
 					return
-				default:
+				case input, ok = <-inputs:
+					if !ok {
+						return
+					}
 				}
 
-				out := fn(ctx, input)
+				// fn must see every input received, even once ctx is
+				// done: it may hold a key slot that only fn releases.
+				out, emit := fn(ctx, worker, input)
+				if !emit {
+					continue
+				}
 				select {
 				case <-ctx.Done():
 					return
@@ -53,25 +486,29 @@ func (p *Pool[In, Out]) Run(ctx context.Context, inputs []In, fn TaskFunc[In, Ou
 	}
 
 	go func() {
-		defer close(jobs)
-		for _, input := range inputs {
+		wg.Wait()
+		if after != nil {
+			<-after
+		}
+		close(results)
+	}()
+
+	return results
+}
+
+// Source returns a channel that yields items in order. It is closed after
+// the last item, or early when ctx is done.
+func Source[T any](ctx context.Context, items []T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for _, item := range items {
 			select {
 			case <-ctx.Done():
 				return
-			case jobs <- input:
+			case ch <- item:
 			}
 		}
 	}()
-
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	out := make([]Out, 0, len(inputs))
-	for result := range results {
-		out = append(out, result)
-	}
-
-	return out
+	return ch
 }
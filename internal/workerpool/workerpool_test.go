@@ -2,8 +2,10 @@ package workerpool
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -93,6 +95,409 @@ func TestPoolRunContextCancellation(t *testing.T) {
 	}
 }
 
+func TestPoolStreamEmitsAsTasksComplete(t *testing.T) {
+	pool := New[time.Duration, time.Duration](2)
+	inputs := make(chan time.Duration)
+	go func() {
+		defer close(inputs)
+		inputs <- 500 * time.Millisecond
+		inputs <- time.Millisecond
+	}()
+
+	start := time.Now()
+	results := pool.Stream(context.Background(), inputs, func(ctx context.Context, d time.Duration) time.Duration {
+		time.Sleep(d)
+		return d
+	})
+
+	first := <-results
+	if first != time.Millisecond {
+		t.Fatalf("first result = %s, want the fast task", first)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("first result after %s, want it before the slow task finishes", elapsed)
+	}
+
+	var rest []time.Duration
+	for result := range results {
+		rest = append(rest, result)
+	}
+	if len(rest) != 1 || rest[0] != 500*time.Millisecond {
+		t.Fatalf("remaining results = %v, want [500ms]", rest)
+	}
+}
+
+func TestPoolStreamUnboundedInputBoundedInFlight(t *testing.T) {
+	pool := New[int, int](3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var produced atomic.Int64
+	inputs := make(chan int)
+	go func() {
+		defer close(inputs)
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case inputs <- i:
+				produced.Add(1)
+			}
+		}
+	}()
+
+	results := pool.Stream(ctx, inputs, func(ctx context.Context, n int) int { return n })
+
+	received := 0
+	for range results {
+		received++
+		if received == 1000 {
+			break
+		}
+	}
+
+	// Each worker holds at most one input plus one pending output.
+	if got := produced.Load(); got > int64(received)+2*3 {
+		t.Fatalf("produced %d inputs for %d results, want bounded read-ahead", got, received)
+	}
+
+	cancel()
+	for range results {
+	}
+}
+
+func TestPoolStreamContextCancellationClosesOutput(t *testing.T) {
+	pool := New[int, int](2)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	inputs := make(chan int)
+	results := pool.Stream(ctx, inputs, func(ctx context.Context, n int) int { return n })
+
+	inputs <- 1
+	if got := <-results; got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-results:
+		if ok {
+			t.Fatal("expected closed channel after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("results channel not closed after cancellation")
+	}
+}
+
+func TestSource(t *testing.T) {
+	var got []int
+	for v := range Source(context.Background(), []int{3, 1, 2}) {
+		got = append(got, v)
+	}
+	if fmt.Sprint(got) != "[3 1 2]" {
+		t.Fatalf("Source yielded %v, want [3 1 2]", got)
+	}
+}
+
+func TestPoolRunOrderedAlignsToInputs(t *testing.T) {
+	pool := New[int, string](4)
+	inputs := make([]int, 20)
+	for i := range inputs {
+		inputs[i] = i
+	}
+
+	results := pool.RunOrdered(context.Background(), inputs, func(ctx context.Context, n int) string {
+		time.Sleep(time.Duration(20-n) * time.Millisecond)
+		return fmt.Sprintf("item_%d", n)
+	})
+
+	if len(results) != len(inputs) {
+		t.Fatalf("got %d results, want %d", len(results), len(inputs))
+	}
+	for i, result := range results {
+		if want := fmt.Sprintf("item_%d", i); result != want {
+			t.Errorf("results[%d] = %q, want %q", i, result, want)
+		}
+	}
+}
+
+func TestPoolRunOrderedEmptyInput(t *testing.T) {
+	pool := New[int, int](2)
+	if results := pool.RunOrdered(context.Background(), nil, func(ctx context.Context, n int) int { return n }); results != nil {
+		t.Fatalf("expected nil, got %v", results)
+	}
+}
+
+func TestPoolStreamOrderedBoundedWindow(t *testing.T) {
+	const window = 4
+	pool := New[int, int](3)
+
+	var started, emitted atomic.Int64
+	var maxOutstanding atomic.Int64
+	fn := func(ctx context.Context, n int) int {
+		outstanding := started.Add(1) - emitted.Load()
+		for {
+			old := maxOutstanding.Load()
+			if outstanding <= old || maxOutstanding.CompareAndSwap(old, outstanding) {
+				break
+			}
+		}
+		// Early inputs are slowest, forcing later results to be held.
+		if n%5 == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return n
+	}
+
+	inputs := make([]int, 30)
+	for i := range inputs {
+		inputs[i] = i
+	}
+
+	next := 0
+	for result := range pool.StreamOrdered(context.Background(), Source(context.Background(), inputs), window, fn) {
+		if result != next {
+			t.Fatalf("got %d, want %d", result, next)
+		}
+		next++
+		emitted.Add(1)
+	}
+	if next != len(inputs) {
+		t.Fatalf("got %d results, want %d", next, len(inputs))
+	}
+	// emitted is bumped after the receive returns, so it can lag the pool
+	// by the one result just handed over.
+	if got := maxOutstanding.Load(); got > window+1 {
+		t.Fatalf("max outstanding = %d, want <= %d", got, window+1)
+	}
+}
+
+func TestPoolStreamOrderedContextCancellation(t *testing.T) {
+	pool := New[int, int](2)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	inputs := make(chan int)
+	go func() {
+		defer close(inputs)
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case inputs <- i:
+			}
+		}
+	}()
+
+	results := pool.StreamOrdered(ctx, inputs, 0, func(ctx context.Context, n int) int { return n })
+	for i := 0; i < 10; i++ {
+		if got := <-results; got != i {
+			t.Fatalf("got %d, want %d", got, i)
+		}
+	}
+
+	cancel()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-results:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("results channel not closed after cancellation")
+		}
+	}
+}
+
+func TestPoolRunECollectAll(t *testing.T) {
+	pool := New[int, int](3)
+	inputs := []int{1, 2, 3, 4, 5, 6}
+	errOdd := errors.New("odd input")
+
+	result := pool.RunE(context.Background(), inputs, CollectAll, func(ctx context.Context, n int) (int, error) {
+		if n%2 == 1 {
+			return 0, errOdd
+		}
+		return n * 10, nil
+	})
+
+	if fmt.Sprint(result.Outputs) != "[0 20 0 40 0 60]" {
+		t.Fatalf("outputs = %v", result.Outputs)
+	}
+	if len(result.Errors) != 3 || result.Errors[0].Index != 0 || result.Errors[1].Index != 2 || result.Errors[2].Index != 4 {
+		t.Fatalf("errors = %v, want indexes 0, 2, 4", result.Errors)
+	}
+	if len(result.Skipped) != 0 {
+		t.Fatalf("skipped = %v, want none", result.Skipped)
+	}
+	if !errors.Is(result.Err, errOdd) {
+		t.Fatalf("Err = %v, want it to wrap errOdd", result.Err)
+	}
+	var taskErr TaskError
+	if !errors.As(result.Err, &taskErr) || taskErr.Index != 0 {
+		t.Fatalf("errors.As(TaskError) = %v, want index 0", taskErr)
+	}
+}
+
+func TestPoolRunEFailFastCancelsSiblings(t *testing.T) {
+	pool := New[int, int](2)
+	inputs := make([]int, 50)
+	for i := range inputs {
+		inputs[i] = i
+	}
+
+	var siblingCancelled atomic.Bool
+	result := pool.RunE(context.Background(), inputs, FailFast, func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			time.Sleep(10 * time.Millisecond)
+			return 0, errors.New("boom")
+		}
+		select {
+		case <-ctx.Done():
+			siblingCancelled.Store(true)
+			return 0, nil
+		case <-time.After(time.Second):
+			return n, nil
+		}
+	})
+
+	if len(result.Errors) != 1 || result.Errors[0].Index != 0 {
+		t.Fatalf("errors = %v, want only index 0", result.Errors)
+	}
+	if !siblingCancelled.Load() {
+		t.Fatal("expected the in-flight sibling to see cancellation")
+	}
+	if len(result.Skipped) < len(inputs)-2 {
+		t.Fatalf("skipped %d tasks, want at least %d", len(result.Skipped), len(inputs)-2)
+	}
+	if errors.Is(result.Err, context.Canceled) {
+		t.Fatalf("Err = %v, should not report the caller's context", result.Err)
+	}
+}
+
+func TestPoolRunEMaxErrors(t *testing.T) {
+	pool := New[int, int](1)
+	inputs := []int{0, 1, 2, 3, 4, 5}
+
+	result := pool.RunE(context.Background(), inputs, MaxErrors(2), func(ctx context.Context, n int) (int, error) {
+		if n == 1 || n == 3 || n == 4 {
+			return 0, fmt.Errorf("fail %d", n)
+		}
+		return n, nil
+	})
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("errors = %v, want 2", result.Errors)
+	}
+	if fmt.Sprint(result.Skipped) != "[4 5]" {
+		t.Fatalf("skipped = %v, want [4 5]", result.Skipped)
+	}
+}
+
+func TestPoolRunECallerCancellationReportsSkipped(t *testing.T) {
+	pool := New[int, int](1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	result := pool.RunE(ctx, []int{0, 1, 2}, CollectAll, func(ctx context.Context, n int) (int, error) {
+		cancel()
+		return n + 100, nil
+	})
+
+	if result.Outputs[0] != 100 {
+		t.Fatalf("outputs[0] = %d, want 100 to be kept after cancellation", result.Outputs[0])
+	}
+	if fmt.Sprint(result.Skipped) != "[1 2]" {
+		t.Fatalf("skipped = %v, want [1 2]", result.Skipped)
+	}
+	if !errors.Is(result.Err, context.Canceled) {
+		t.Fatalf("Err = %v, want context.Canceled", result.Err)
+	}
+}
+
+func TestPoolRunEEmptyInput(t *testing.T) {
+	result := New[int, int](2).RunE(context.Background(), nil, FailFast, func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	})
+	if result.Err != nil || len(result.Outputs) != 0 {
+		t.Fatalf("result = %+v, want empty", result)
+	}
+}
+
+func panicOnThree(ctx context.Context, n int) int {
+	if n == 3 {
+		panic("three")
+	}
+	return n
+}
+
+func TestPoolRunRecoversPanicsAndKeepsWorker(t *testing.T) {
+	// One worker: if the panic killed it, later inputs would never run.
+	pool := New[int, int](1)
+
+	results := pool.Run(context.Background(), []int{1, 2, 3, 4, 5}, panicOnThree)
+
+	sort.Ints(results)
+	if fmt.Sprint(results) != "[1 2 4 5]" {
+		t.Fatalf("results = %v, want the panicking task omitted", results)
+	}
+}
+
+func TestPoolPanicHandler(t *testing.T) {
+	var got *PanicError
+	pool := New[int, int](2).WithPanicHandler(func(n int, err *PanicError) int {
+		got = err
+		return -n
+	})
+
+	results := pool.RunOrdered(context.Background(), []int{1, 2, 3, 4}, panicOnThree)
+
+	if fmt.Sprint(results) != "[1 2 -3 4]" {
+		t.Fatalf("results = %v, want [1 2 -3 4]", results)
+	}
+	if got == nil || got.Value != "three" || got.Error() != "panic: three" {
+		t.Fatalf("panic error = %+v", got)
+	}
+	if !strings.Contains(string(got.Stack), "panicOnThree") {
+		t.Fatalf("stack does not mention the panicking function:\n%s", got.Stack)
+	}
+}
+
+func TestPoolStreamOrderedSkipsPanickedTask(t *testing.T) {
+	pool := New[int, int](2)
+	inputs := []int{1, 2, 3, 4, 5}
+
+	var got []int
+	for result := range pool.StreamOrdered(context.Background(), Source(context.Background(), inputs), 2, panicOnThree) {
+		got = append(got, result)
+	}
+	if fmt.Sprint(got) != "[1 2 4 5]" {
+		t.Fatalf("results = %v, want [1 2 4 5]", got)
+	}
+}
+
+func TestPoolRunEReportsPanicAsTaskError(t *testing.T) {
+	errBoom := errors.New("boom")
+	pool := New[int, int](2)
+
+	result := pool.RunE(context.Background(), []int{1, 2}, CollectAll, func(ctx context.Context, n int) (int, error) {
+		if n == 2 {
+			panic(errBoom)
+		}
+		return n, nil
+	})
+
+	var panicErr *PanicError
+	if !errors.As(result.Err, &panicErr) || len(panicErr.Stack) == 0 {
+		t.Fatalf("Err = %v, want a *PanicError with a stack", result.Err)
+	}
+	if !errors.Is(result.Err, errBoom) {
+		t.Fatalf("Err = %v, want it to unwrap to the panic value", result.Err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Index != 1 || result.Outputs[0] != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
 func BenchmarkPoolRun(b *testing.B) {
 	pool := New[int, int](8)
 	inputs := make([]int, 1000)